@@ -0,0 +1,174 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dictyBase/arangomanager"
+	"github.com/dictyBase/arangomanager/command/flag"
+	"github.com/urfave/cli"
+)
+
+/*
+The Command function returns a cli.Command for inspecting and cleaning up
+the stream transactions of an ArangoDB database. It has two subcommands:
+
+  - list: Prints the ID and the state of every stream transaction known to
+    the server.
+
+  - abort-stale: Polls the transactions every --interval until it is
+    interrupted and aborts the ones that are still running after the
+    duration given by --older-than. As the server does not report when a
+    transaction began, their age is counted from the first poll that listed
+    them, so nothing is aborted before --older-than has passed. With
+    --dry-run the stale transactions are only printed at every poll.
+
+Both subcommands take the connection flags of flag.ArangodbFlags.
+
+Example usage:
+
+	app := cli.NewApp()
+	app.Commands = []cli.Command{transaction.Command()}
+	...
+	err := app.Run(os.Args)
+*/
+func Command() cli.Command {
+	return cli.Command{
+		Name:  "transactions",
+		Usage: "inspect and abort the stream transactions of a database",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "list the stream transactions",
+				Flags:  flag.ArangodbFlags(),
+				Action: listAction,
+			},
+			{
+				Name:  "abort-stale",
+				Usage: "abort the stream transactions running longer than a duration",
+				Flags: append(
+					flag.ArangodbFlags(),
+					cli.DurationFlag{
+						Name:  "older-than",
+						Usage: "abort the transactions still running after this duration",
+						Value: 10 * time.Minute,
+					},
+					cli.DurationFlag{
+						Name:  "interval",
+						Usage: "time between two polls of the transactions",
+						Value: time.Minute,
+					},
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "only print the stale transactions",
+					},
+				),
+				Action: abortStaleAction,
+			},
+		},
+	}
+}
+
+func connect(c *cli.Context) (*arangomanager.Database, error) {
+	_, dbh, err := arangomanager.NewSessionDb(&arangomanager.ConnectParams{
+		User:     c.String("arangodb-user"),
+		Pass:     c.String("arangodb-pass"),
+		Database: c.String("arangodb-database"),
+		Host:     c.String("arangodb-host"),
+		Port:     c.Int("arangodb-port"),
+		Istls:    c.Bool("is-secure"),
+	})
+
+	return dbh, err
+}
+
+func listAction(c *cli.Context) error {
+	dbh, err := connect(c)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to connect: %s", err), 1)
+	}
+	txs, err := dbh.ListTransactions(context.Background())
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	if err := printTransactions(c.App.Writer, txs); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	return nil
+}
+
+func abortStaleAction(c *cli.Context) error {
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		return cli.NewExitError("interval has to be positive", 1)
+	}
+	dbh, err := connect(c)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to connect: %s", err), 1)
+	}
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := abortStale(ctx, c, dbh); err != nil && ctx.Err() == nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// abortStale aborts or, with --dry-run, prints the stale transactions of a
+// single poll.
+func abortStale(
+	ctx context.Context,
+	c *cli.Context,
+	dbh *arangomanager.Database,
+) error {
+	olderThan := c.Duration("older-than")
+	if c.Bool("dry-run") {
+		stale, err := dbh.StaleTransactions(ctx, olderThan)
+		if err != nil {
+			return err
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+
+		return printTransactions(c.App.Writer, stale)
+	}
+	aborted, err := dbh.AbortStaleTransactions(ctx, olderThan)
+	for _, id := range aborted {
+		fmt.Fprintf(c.App.Writer, "aborted %s\n", id)
+	}
+
+	return err
+}
+
+func printTransactions(
+	wrt io.Writer,
+	txs []*arangomanager.TransactionInfo,
+) error {
+	tbw := tabwriter.NewWriter(wrt, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tbw, "ID\tSTATE")
+	for _, trx := range txs {
+		fmt.Fprintf(tbw, "%s\t%s\n", trx.ID, trx.State)
+	}
+
+	return tbw.Flush()
+}
@@ -4,12 +4,20 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/url"
+	"path"
+	"slices"
 	"time"
 
 	driver "github.com/arangodb/go-driver"
+	validator "github.com/go-playground/validator/v10"
 )
 
-const tranSize = 12
+const (
+	tranSize            = 12
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 50 * time.Millisecond
+)
 
 // TransactionOptions represents options for transaction operations
 type TransactionOptions struct {
@@ -21,26 +29,51 @@ type TransactionOptions struct {
 	ExclusiveCollections []string
 	// WaitForSync if set to true, will force the transaction to write all data to disk before returning
 	WaitForSync bool
-	// AllowImplicit if set to true, allows reading from undeclared collections (only for Transaction)
+	// AllowImplicit if set to true, allows reading from undeclared collections
 	AllowImplicit bool
 	// LockTimeout the timeout for waiting on collection locks (in seconds)
-	LockTimeout int
+	LockTimeout int `validate:"gte=0"`
 	// MaxTransactionSize the maximum size of the transaction in bytes
-	MaxTransactionSize int
+	MaxTransactionSize int `validate:"gte=0"`
+	// MaxRetries is the number of times RunInTransaction runs the
+	// transaction again after a write-write conflict or a lock timeout
+	MaxRetries int `validate:"gte=0"`
+	// RetryBackoff is the delay before the first retry of RunInTransaction,
+	// it doubles with every further retry
+	RetryBackoff time.Duration `validate:"gte=0"`
+	// IdleTimeout aborts the transaction when no operation was run through
+	// the TransactionHandler for the given duration, disabled if zero
+	IdleTimeout time.Duration `validate:"gte=0"`
 }
 
 // Database struct.
 type Database struct {
 	dbh driver.Database
+	// connection of the client, used for the server APIs the driver has
+	// no methods for
+	conn driver.Connection
+	// first time the transactions were begun or listed, shared by the
+	// databases of a session
+	seen *txFirstSeen
+	// running transactions of the session, nil if they are not tracked
+	tracker *txTracker
+	// check the bind parameters of the queries, see WithBindVarChecks
+	checkBindVars bool
 }
 
 // DefaultTransactionOptions returns default options for transactions
 func DefaultTransactionOptions() *TransactionOptions {
 	return &TransactionOptions{
 		MaxTransactionSize: int(math.Pow10(tranSize)),
+		MaxRetries:         defaultMaxRetries,
+		RetryBackoff:       defaultRetryBackoff,
 	}
 }
 
+// BeginTransaction begins a stream transaction with all the given options,
+// DefaultTransactionOptions is used if opts is nil. Returns an error for
+// negative sizes or timeouts and for collections that are locked
+// exclusively as well as declared for reading or writing.
 func (d *Database) BeginTransaction(
 	ctx context.Context,
 	opts *TransactionOptions,
@@ -48,34 +81,107 @@ func (d *Database) BeginTransaction(
 	if opts == nil {
 		opts = DefaultTransactionOptions()
 	}
-
-	// Create transaction options
-	beginOpts := &driver.BeginTransactionOptions{
-		// Set max transaction size with safety checks
-		MaxTransactionSize: uint64(0), // Default to 0
-	}
-	beginOpts.WaitForSync = opts.WaitForSync
-	if opts.LockTimeout > 0 {
-		beginOpts.LockTimeout = time.Duration(opts.LockTimeout) * time.Second
+	if err := validateTransactionOptions(opts); err != nil {
+		return nil, err
 	}
 	// Begin transaction
-	txID, err := d.dbh.BeginTransaction(
-		ctx, driver.TransactionCollections{
-			Read:      opts.ReadCollections,
-			Write:     opts.WriteCollections,
-			Exclusive: opts.ExclusiveCollections,
-		}, beginOpts)
+	txID, err := d.beginTransaction(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	// Create transaction context
 	txCtx := driver.WithTransactionID(ctx, txID)
-	return &TransactionHandler{
-		db:       d,
-		id:       txID,
-		ctx:      txCtx,
-		canceled: false,
-	}, nil
+	trx := &TransactionHandler{
+		db:          d,
+		id:          txID,
+		ctx:         txCtx,
+		parent:      ctx,
+		state:       TxRunning,
+		idleTimeout: opts.IdleTimeout,
+	}
+	d.seen.mark(txID)
+	d.tracker.add(trx)
+	trx.startIdleTimer()
+
+	return trx, nil
+}
+
+// beginTransactionRequest is the body of the begin request of a stream
+// transaction, the driver does not send the maximum transaction size.
+type beginTransactionRequest struct {
+	WaitForSync        bool                          `json:"waitForSync,omitempty"`
+	AllowImplicit      bool                          `json:"allowImplicit,omitempty"`
+	LockTimeout        float64                       `json:"lockTimeout,omitempty"`
+	MaxTransactionSize uint64                        `json:"maxTransactionSize,omitempty"`
+	Collections        driver.TransactionCollections `json:"collections"`
+}
+
+func (d *Database) beginTransaction(
+	ctx context.Context,
+	opts *TransactionOptions,
+) (driver.TransactionID, error) {
+	colls := driver.TransactionCollections{
+		Read:      opts.ReadCollections,
+		Write:     opts.WriteCollections,
+		Exclusive: opts.ExclusiveCollections,
+	}
+	lockTimeout := time.Duration(opts.LockTimeout) * time.Second
+	if d.conn == nil {
+		return d.dbh.BeginTransaction(ctx, colls, &driver.BeginTransactionOptions{
+			WaitForSync:        opts.WaitForSync,
+			AllowImplicit:      opts.AllowImplicit,
+			LockTimeout:        lockTimeout,
+			MaxTransactionSize: uint64(opts.MaxTransactionSize),
+		})
+	}
+	req, err := d.conn.NewRequest(
+		"POST",
+		path.Join("_db", url.PathEscape(d.dbh.Name()), "_api/transaction/begin"),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error in creating begin request %s", err)
+	}
+	if _, err := req.SetBody(&beginTransactionRequest{
+		WaitForSync:        opts.WaitForSync,
+		AllowImplicit:      opts.AllowImplicit,
+		LockTimeout:        lockTimeout.Seconds(),
+		MaxTransactionSize: uint64(opts.MaxTransactionSize),
+		Collections:        colls,
+	}); err != nil {
+		return "", fmt.Errorf("error in setting begin request body %s", err)
+	}
+	resp, err := d.conn.Do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if err := resp.CheckStatus(201); err != nil {
+		return "", err
+	}
+	var result struct {
+		ID driver.TransactionID `json:"id"`
+	}
+	if err := resp.ParseBody("result", &result); err != nil {
+		return "", fmt.Errorf("error in decoding begin response %s", err)
+	}
+
+	return result.ID, nil
+}
+
+func validateTransactionOptions(opts *TransactionOptions) error {
+	if err := validator.New().Struct(opts); err != nil {
+		return fmt.Errorf("invalid transaction options %w", err)
+	}
+	for _, coll := range opts.ExclusiveCollections {
+		if slices.Contains(opts.ReadCollections, coll) ||
+			slices.Contains(opts.WriteCollections, coll) {
+			return fmt.Errorf(
+				"collection %s is declared exclusive and read or write",
+				coll,
+			)
+		}
+	}
+
+	return nil
 }
 
 // Handler returns the raw arangodb database handler.
@@ -84,21 +190,26 @@ func (d *Database) Handler() driver.Database {
 }
 
 // SearchRows query the database with bind parameters that is expected to return
-// multiple rows of result.
+// multiple rows of result. Missing or unused bind parameters are rejected
+// before running the query if the session was created WithBindVarChecks.
 func (d *Database) SearchRows(
 	query string,
 	bindVars map[string]interface{},
+) (*Resultset, error) {
+	return d.SearchRowsContext(context.Background(), query, bindVars)
+}
+
+// SearchRowsContext is SearchRows with a context for the query and for
+// reading its rows.
+func (d *Database) SearchRowsContext(
+	ctx context.Context,
+	query string,
+	bindVars map[string]interface{},
 ) (*Resultset, error) {
 	// validate
-	if err := d.dbh.ValidateQuery(context.Background(), query); err != nil {
-		return &Resultset{
-				empty: true,
-			}, fmt.Errorf(
-				"error in validating the query %s",
-				err,
-			)
+	if err := d.validateQuery(ctx, query, bindVars); err != nil {
+		return &Resultset{empty: true}, err
 	}
-	ctx := context.Background()
 	cqr, err := d.dbh.Query(ctx, query, bindVars)
 	if err != nil {
 		return &Resultset{
@@ -127,8 +238,8 @@ func (d *Database) CountWithParams(
 	bindVars map[string]interface{},
 ) (int64, error) {
 	// validate
-	if err := d.dbh.ValidateQuery(context.Background(), query); err != nil {
-		return 0, fmt.Errorf("error in validating the query %s", err)
+	if err := d.validateQuery(context.Background(), query, bindVars); err != nil {
+		return 0, err
 	}
 	cobj, err := d.dbh.Query(
 		driver.WithQueryCount(context.Background(), true),
@@ -154,9 +265,16 @@ func (d *Database) Exec(query string) error {
 }
 
 // Do is to run data modification query with bind parameters that is not
-// expected to return any result.
+// expected to return any result. Missing or unused bind parameters are
+// rejected before running the query if the session was created
+// WithBindVarChecks, the query is not validated otherwise.
 func (d *Database) Do(query string, bindVars map[string]interface{}) error {
 	ctx := driver.WithSilent(context.Background())
+	if d.checkBindVars {
+		if err := d.validateQuery(ctx, query, bindVars); err != nil {
+			return err
+		}
+	}
 	_, err := d.dbh.Query(ctx, query, bindVars)
 	if err != nil {
 		return fmt.Errorf("error in data modification query %s", err)
@@ -171,15 +289,19 @@ func (d *Database) GetRow(
 	query string,
 	bindVars map[string]interface{},
 ) (*Result, error) {
-	if err := d.dbh.ValidateQuery(context.Background(), query); err != nil {
-		return &Result{
-				empty: true,
-			}, fmt.Errorf(
-				"error in validating the query %s",
-				err,
-			)
+	return d.GetRowContext(context.Background(), query, bindVars)
+}
+
+// GetRowContext is GetRow with a context for the query.
+func (d *Database) GetRowContext(
+	ctx context.Context,
+	query string,
+	bindVars map[string]interface{},
+) (*Result, error) {
+	if err := d.validateQuery(ctx, query, bindVars); err != nil {
+		return &Result{empty: true}, err
 	}
-	cqr, err := d.dbh.Query(context.Background(), query, bindVars)
+	cqr, err := d.dbh.Query(ctx, query, bindVars)
 
 	return d.getResult(cqr, err)
 }
@@ -430,7 +552,7 @@ func (d *Database) Truncate(names ...string) error {
 
 func (d *Database) getResult(cdr driver.Cursor, err error) (*Result, error) {
 	if err != nil {
-		return &Result{empty: true}, fmt.Errorf("error in query %s", err)
+		return &Result{empty: true}, fmt.Errorf("error in query %w", err)
 	}
 	if !cdr.HasMore() {
 		return &Result{empty: true}, nil
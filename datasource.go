@@ -8,4 +8,10 @@ type ConnectParams struct {
 	Host     string `validate:"required"`
 	Port     int    `validate:"required"`
 	Istls    bool
+	// TrackTransactions aborts the running transactions of the session on
+	// Session.Close
+	TrackTransactions bool
+	// CheckBindVars rejects missing and unused bind parameters before
+	// running the queries, see WithBindVarChecks
+	CheckBindVars bool
 }
@@ -0,0 +1,447 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	driver "github.com/arangodb/go-driver"
+	validator "github.com/go-playground/validator/v10"
+)
+
+var (
+	// ErrNotFound is matched by the errors of operations on documents that
+	// do not exist.
+	ErrNotFound = errors.New("document not found")
+	// ErrConflict is matched by the errors of operations that conflict with
+	// an existing document, i.e. an insert with a duplicate key.
+	ErrConflict = errors.New("document conflict")
+)
+
+// DocumentError is returned by the document helpers, it wraps the error of
+// the server and matches ErrNotFound and ErrConflict.
+type DocumentError struct {
+	// Op is the failed operation, i.e. insert or read
+	Op         string
+	Collection string
+	// Key of the document, empty for new documents without a key
+	Key string
+	Err error
+}
+
+func (e *DocumentError) Error() string {
+	ref := e.Collection
+	if e.Key != "" {
+		ref = fmt.Sprintf("%s/%s", e.Collection, e.Key)
+	}
+
+	return fmt.Sprintf("error in %s document %s %s", e.Op, ref, e.Err)
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrNotFound and ErrConflict by the error of the server.
+func (e *DocumentError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return driver.IsArangoErrorWithErrorNum(
+			e.Err,
+			driver.ErrArangoDocumentNotFound,
+		)
+	case ErrConflict:
+		return driver.IsConflict(e.Err)
+	}
+
+	return false
+}
+
+// DocOptions are the options of the document helpers, nil is the same as
+// the zero value.
+type DocOptions struct {
+	// ReturnNew fills DocumentResult.New with the document as stored by the
+	// operation, it is ignored by Remove
+	ReturnNew bool
+	// ReturnOld fills DocumentResult.Old with the document before the
+	// operation, it is only used by Update, Replace and Remove
+	ReturnOld bool
+	// WaitForSync waits until the operation is synced to disk
+	WaitForSync bool
+	// OverwriteMode decides what Insert and InsertMany do with a document
+	// whose key exists, by default they return a conflict
+	OverwriteMode driver.OverwriteMode `validate:"omitempty,oneof=ignore replace update conflict"`
+	// MaxRetries is the number of times UpdateWithRetry reads and mutates
+	// the document again after a revision conflict, 5 if zero
+	MaxRetries int `validate:"gte=0"`
+}
+
+// DocumentResult is the result of a document operation.
+type DocumentResult[T any] struct {
+	Meta driver.DocumentMeta
+	// New is the stored document if DocOptions.ReturnNew is set
+	New *T
+	// Old is the previous document if DocOptions.ReturnOld is set
+	Old *T
+}
+
+// Insert creates the document in the collection.
+func Insert[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	doc T,
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	res := &DocumentResult[T]{}
+	dctx, err := documentContext(ctx, opts, res)
+	if err != nil {
+		return nil, err
+	}
+	coll, err := dbh.documentCollection(ctx, "insert", collection)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := coll.CreateDocument(dctx, doc)
+	if err != nil {
+		return nil, &DocumentError{Op: "insert", Collection: collection, Err: err}
+	}
+
+	return res, res.setMeta(meta)
+}
+
+// InsertMany creates the documents in the collection with a single request.
+// The results follow the order of the documents, the documents that could
+// not be created have a nil result and a DocumentError in the returned
+// error.
+func InsertMany[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	docs []T,
+	opts *DocOptions,
+) ([]*DocumentResult[T], error) {
+	if err := validateDocOptions(opts); err != nil {
+		return nil, err
+	}
+	coll, err := dbh.documentCollection(ctx, "insert", collection)
+	if err != nil {
+		return nil, err
+	}
+	dctx := documentOptionsContext(ctx, opts)
+	var created []T
+	if opts != nil && opts.ReturnNew {
+		created = make([]T, len(docs))
+		dctx = driver.WithReturnNew(dctx, created)
+	}
+	metas, errs, err := coll.CreateDocuments(dctx, docs)
+	if err != nil {
+		return nil, &DocumentError{Op: "insert", Collection: collection, Err: err}
+	}
+	results := make([]*DocumentResult[T], len(docs))
+	var derrs []error
+	for idx := range docs {
+		if idx < len(errs) && errs[idx] != nil {
+			derrs = append(derrs, fmt.Errorf("document %d %w", idx, &DocumentError{
+				Op: "insert", Collection: collection, Err: errs[idx],
+			}))
+
+			continue
+		}
+		res := &DocumentResult[T]{}
+		if created != nil {
+			res.New = &created[idx]
+		}
+		if err := res.setMeta(metas[idx]); err != nil {
+			return nil, err
+		}
+		results[idx] = res
+	}
+
+	return results, errors.Join(derrs...)
+}
+
+// Get reads the document with the key from the collection, the
+// DocumentMeta embedded in T is filled in.
+func Get[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection, key string,
+) (T, error) {
+	var doc T
+	coll, err := dbh.documentCollection(ctx, "read", collection)
+	if err != nil {
+		return doc, err
+	}
+	meta, err := coll.ReadDocument(ctx, key, &doc)
+	if err != nil {
+		return doc, &DocumentError{
+			Op: "read", Collection: collection, Key: key, Err: err,
+		}
+	}
+
+	return doc, setDocumentMeta(documentRef(&doc), meta)
+}
+
+// ReadMany reads the documents with the keys from the collection with a
+// single request. The documents follow the order of the keys, a missing
+// document is left as the zero value and reported with a DocumentError
+// matching ErrNotFound in the returned error.
+func ReadMany[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	keys []string,
+) ([]T, error) {
+	coll, err := dbh.documentCollection(ctx, "read", collection)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]T, len(keys))
+	metas, errs, err := coll.ReadDocuments(ctx, keys, docs)
+	if err != nil {
+		return nil, &DocumentError{Op: "read", Collection: collection, Err: err}
+	}
+	var derrs []error
+	for idx, key := range keys {
+		if idx < len(errs) && errs[idx] != nil {
+			derrs = append(derrs, &DocumentError{
+				Op: "read", Collection: collection, Key: key, Err: errs[idx],
+			})
+
+			continue
+		}
+		if err := setDocumentMeta(documentRef(&docs[idx]), metas[idx]); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, errors.Join(derrs...)
+}
+
+// Update partially updates the document with the key, update holds the
+// attributes to change.
+func Update[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection, key string,
+	update interface{},
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	res := &DocumentResult[T]{}
+	dctx, err := documentContext(ctx, opts, res)
+	if err != nil {
+		return nil, err
+	}
+	coll, err := dbh.documentCollection(ctx, "update", collection)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := coll.UpdateDocument(dctx, key, update)
+	if err != nil {
+		return nil, &DocumentError{
+			Op: "update", Collection: collection, Key: key, Err: err,
+		}
+	}
+
+	return res, res.setMeta(meta)
+}
+
+// Replace replaces the document with the key.
+func Replace[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection, key string,
+	doc T,
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	res := &DocumentResult[T]{}
+	dctx, err := documentContext(ctx, opts, res)
+	if err != nil {
+		return nil, err
+	}
+	coll, err := dbh.documentCollection(ctx, "replace", collection)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := coll.ReplaceDocument(dctx, key, doc)
+	if err != nil {
+		return nil, &DocumentError{
+			Op: "replace", Collection: collection, Key: key, Err: err,
+		}
+	}
+
+	return res, res.setMeta(meta)
+}
+
+// Remove removes the document with the key.
+func Remove[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection, key string,
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	if opts != nil {
+		ropts := *opts
+		ropts.ReturnNew = false
+		opts = &ropts
+	}
+	res := &DocumentResult[T]{}
+	dctx, err := documentContext(ctx, opts, res)
+	if err != nil {
+		return nil, err
+	}
+	coll, err := dbh.documentCollection(ctx, "remove", collection)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := coll.RemoveDocument(dctx, key)
+	if err != nil {
+		return nil, &DocumentError{
+			Op: "remove", Collection: collection, Key: key, Err: err,
+		}
+	}
+
+	return res, res.setMeta(meta)
+}
+
+// RemoveMany removes the documents with the keys from the collection with
+// a single request. The documents that could not be removed are reported
+// with a DocumentError each in the returned error.
+func RemoveMany(
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	keys []string,
+	opts *DocOptions,
+) error {
+	if err := validateDocOptions(opts); err != nil {
+		return err
+	}
+	coll, err := dbh.documentCollection(ctx, "remove", collection)
+	if err != nil {
+		return err
+	}
+	_, errs, err := coll.RemoveDocuments(documentOptionsContext(ctx, opts), keys)
+	if err != nil {
+		return &DocumentError{Op: "remove", Collection: collection, Err: err}
+	}
+	var derrs []error
+	for idx, key := range keys {
+		if idx < len(errs) && errs[idx] != nil {
+			derrs = append(derrs, &DocumentError{
+				Op: "remove", Collection: collection, Key: key, Err: errs[idx],
+			})
+		}
+	}
+
+	return errors.Join(derrs...)
+}
+
+// Exists checks if the document with the key exists in the collection.
+func Exists(
+	ctx context.Context,
+	dbh *Database,
+	collection, key string,
+) (bool, error) {
+	coll, err := dbh.documentCollection(ctx, "read", collection)
+	if err != nil {
+		return false, err
+	}
+	ok, err := coll.DocumentExists(ctx, key)
+	if err != nil {
+		return false, &DocumentError{
+			Op: "read", Collection: collection, Key: key, Err: err,
+		}
+	}
+
+	return ok, nil
+}
+
+func (d *Database) documentCollection(
+	ctx context.Context,
+	op, name string,
+) (driver.Collection, error) {
+	coll, err := d.dbh.Collection(ctx, name)
+	if err != nil {
+		return nil, &DocumentError{Op: op, Collection: name, Err: err}
+	}
+
+	return coll, nil
+}
+
+func (res *DocumentResult[T]) setMeta(meta driver.DocumentMeta) error {
+	res.Meta = meta
+	if res.New == nil {
+		return nil
+	}
+
+	return setDocumentMeta(documentRef(res.New), meta)
+}
+
+// documentRef returns the pointer to the structure of the document for
+// setDocumentMeta, T is either a structure or a pointer to one.
+func documentRef[T any](doc *T) interface{} {
+	if reflect.ValueOf(*doc).Kind() == reflect.Ptr {
+		return *doc
+	}
+
+	return doc
+}
+
+// documentContext returns the context for the options, the returned new and
+// old documents are decoded into res.
+func documentContext[T any](
+	ctx context.Context,
+	opts *DocOptions,
+	res *DocumentResult[T],
+) (context.Context, error) {
+	if err := validateDocOptions(opts); err != nil {
+		return nil, err
+	}
+	dctx := documentOptionsContext(ctx, opts)
+	if opts == nil {
+		return dctx, nil
+	}
+	if opts.ReturnNew {
+		res.New = new(T)
+		dctx = driver.WithReturnNew(dctx, res.New)
+	}
+	if opts.ReturnOld {
+		res.Old = new(T)
+		dctx = driver.WithReturnOld(dctx, res.Old)
+	}
+
+	return dctx, nil
+}
+
+// documentOptionsContext returns the context for the options that do not
+// return documents.
+func documentOptionsContext(
+	ctx context.Context,
+	opts *DocOptions,
+) context.Context {
+	if opts == nil {
+		return ctx
+	}
+	if opts.WaitForSync {
+		ctx = driver.WithWaitForSync(ctx)
+	}
+	if opts.OverwriteMode != "" {
+		ctx = driver.WithOverwriteMode(ctx, opts.OverwriteMode)
+	}
+
+	return ctx
+}
+
+func validateDocOptions(opts *DocOptions) error {
+	if opts == nil {
+		return nil
+	}
+	if err := validator.New().Struct(opts); err != nil {
+		return fmt.Errorf("invalid document options %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,342 @@
+package arangomanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/stretchr/testify/require"
+)
+
+// docServer is an in-memory stand-in for the document API of a single
+// collection named stock.
+type docServer struct {
+	mu   sync.Mutex
+	docs map[string]map[string]interface{}
+	rev  int
+	// query parameters of the write requests
+	params []string
+}
+
+func newDocServer() *docServer {
+	return &docServer{docs: make(map[string]map[string]interface{})}
+}
+
+func (srv *docServer) handle(wrt http.ResponseWriter, req *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	route := strings.TrimPrefix(req.URL.Path, "/_db/"+standInDB+"/_api/")
+	switch {
+	case route == "collection/stock":
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200, "name": "stock", "type": 2,
+		})
+	case strings.HasPrefix(route, "collection/"):
+		writeArangoError(wrt, http.StatusNotFound, 1203, "collection not found")
+	case route == "document/stock" && req.Method == http.MethodPost:
+		srv.params = append(srv.params, req.URL.RawQuery)
+		srv.handleCreate(wrt, req)
+	case route == "document/stock" && req.Method == http.MethodPut:
+		var keys []string
+		_ = json.NewDecoder(req.Body).Decode(&keys)
+		docs := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			doc, ok := srv.docs[key]
+			if !ok {
+				docs = append(docs, arangoError(http.StatusNotFound, 1202, "document not found"))
+				continue
+			}
+			docs = append(docs, doc)
+		}
+		writeJSON(wrt, http.StatusOK, docs)
+	case route == "document/stock" && req.Method == http.MethodDelete:
+		var refs []driver.DocumentMeta
+		_ = json.NewDecoder(req.Body).Decode(&refs)
+		results := make([]interface{}, 0, len(refs))
+		for _, ref := range refs {
+			key := ref.Key
+			doc, ok := srv.docs[key]
+			if !ok {
+				results = append(results, arangoError(http.StatusNotFound, 1202, "document not found"))
+				continue
+			}
+			delete(srv.docs, key)
+			results = append(results, metaOf(doc))
+		}
+		writeJSON(wrt, http.StatusOK, results)
+	case strings.HasPrefix(route, "document/stock/"):
+		key := strings.TrimPrefix(route, "document/stock/")
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			srv.params = append(srv.params, req.URL.RawQuery)
+		}
+		srv.handleDocument(wrt, req, key)
+	default:
+		writeArangoError(wrt, http.StatusNotFound, 404, "unexpected request "+req.URL.Path)
+	}
+}
+
+func (srv *docServer) handleCreate(wrt http.ResponseWriter, req *http.Request) {
+	var body interface{}
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	if docs, ok := body.([]interface{}); ok {
+		results := make([]interface{}, 0, len(docs))
+		for _, doc := range docs {
+			_, res := srv.create(doc.(map[string]interface{}), req)
+			results = append(results, res)
+		}
+		writeJSON(wrt, http.StatusCreated, results)
+
+		return
+	}
+	status, res := srv.create(body.(map[string]interface{}), req)
+	writeJSON(wrt, status, res)
+}
+
+func (srv *docServer) create(
+	doc map[string]interface{},
+	req *http.Request,
+) (int, map[string]interface{}) {
+	key, _ := doc["_key"].(string)
+	if key == "" {
+		key = fmt.Sprintf("k%d", len(srv.docs)+1)
+	}
+	old, exists := srv.docs[key]
+	if exists {
+		switch req.URL.Query().Get("overwriteMode") {
+		case "replace":
+		case "update":
+			for name, value := range old {
+				if _, ok := doc[name]; !ok {
+					doc[name] = value
+				}
+			}
+		case "ignore":
+			return http.StatusAccepted, metaOf(old)
+		default:
+			return http.StatusConflict, arangoError(
+				http.StatusConflict, 1210, "unique constraint violated",
+			)
+		}
+	}
+
+	return http.StatusCreated, srv.store(key, doc, old, req)
+}
+
+func (srv *docServer) handleDocument(
+	wrt http.ResponseWriter,
+	req *http.Request,
+	key string,
+) {
+	old, ok := srv.docs[key]
+	if !ok {
+		if req.Method == http.MethodHead {
+			wrt.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeArangoError(wrt, http.StatusNotFound, 1202, "document not found")
+
+		return
+	}
+	if rev := req.Header.Get("If-Match"); rev != "" && rev != old["_rev"] {
+		writeArangoError(wrt, http.StatusPreconditionFailed, 1200, "conflict, _rev values do not match")
+
+		return
+	}
+	switch req.Method {
+	case http.MethodHead:
+		wrt.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		writeJSON(wrt, http.StatusOK, old)
+	case http.MethodDelete:
+		delete(srv.docs, key)
+		res := metaOf(old)
+		if req.URL.Query().Get("returnOld") == "true" {
+			res["old"] = old
+		}
+		writeJSON(wrt, http.StatusOK, res)
+	case http.MethodPatch, http.MethodPut:
+		var doc map[string]interface{}
+		_ = json.NewDecoder(req.Body).Decode(&doc)
+		if req.Method == http.MethodPatch {
+			for name, value := range old {
+				if _, ok := doc[name]; !ok {
+					doc[name] = value
+				}
+			}
+		}
+		writeJSON(wrt, http.StatusCreated, srv.store(key, doc, old, req))
+	}
+}
+
+// store saves the document with a new revision and returns the response of
+// a write operation.
+func (srv *docServer) store(
+	key string,
+	doc, old map[string]interface{},
+	req *http.Request,
+) map[string]interface{} {
+	srv.rev++
+	doc["_key"] = key
+	doc["_id"] = "stock/" + key
+	doc["_rev"] = fmt.Sprintf("_r%d", srv.rev)
+	srv.docs[key] = doc
+	res := metaOf(doc)
+	if old != nil {
+		res["_oldRev"] = old["_rev"]
+	}
+	if req.URL.Query().Get("returnNew") == "true" {
+		res["new"] = doc
+	}
+	if old != nil && req.URL.Query().Get("returnOld") == "true" {
+		res["old"] = old
+	}
+
+	return res
+}
+
+func metaOf(doc map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"_key": doc["_key"], "_id": doc["_id"], "_rev": doc["_rev"],
+	}
+}
+
+func arangoError(code, num int, msg string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": true, "code": code, "errorNum": num, "errorMessage": msg,
+	}
+}
+
+func writeArangoError(wrt http.ResponseWriter, code, num int, msg string) {
+	writeJSON(wrt, code, arangoError(code, num, msg))
+}
+
+type stockItem struct {
+	driver.DocumentMeta
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestInsertAndGet(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	dbh := newStandInDatabase(t, newDocServer().handle)
+	res, err := Insert(ctx, dbh, "stock", &stockItem{Name: "gel", Count: 4}, nil)
+	assert.NoError(err, "should insert the document")
+	assert.Equal("k1", res.Meta.Key, "should return the meta of the document")
+	assert.Nil(res.New, "should not return the new document by default")
+	item, err := Get[stockItem](ctx, dbh, "stock", "k1")
+	assert.NoError(err, "should get the document")
+	assert.Equal("gel", item.Name, "should match the name")
+	assert.Equal(res.Meta.Rev, item.Rev, "should fill the DocumentMeta")
+	_, err = Get[stockItem](ctx, dbh, "stock", "k9")
+	assert.ErrorIs(err, ErrNotFound, "should return not found error")
+	var derr *DocumentError
+	assert.ErrorAs(err, &derr, "should return a DocumentError")
+	assert.Equal("k9", derr.Key, "should report the key")
+	assert.EqualError(
+		err,
+		"error in read document stock/k9 document not found",
+		"should match the error message",
+	)
+	_, err = Get[stockItem](ctx, dbh, "order", "k1")
+	assert.Error(err, "should return error for missing collection")
+	assert.NotErrorIs(err, ErrNotFound, "should not report a missing collection as missing document")
+	ok, err := Exists(ctx, dbh, "stock", "k1")
+	assert.NoError(err, "should check the document")
+	assert.True(ok, "should find the document")
+	ok, err = Exists(ctx, dbh, "stock", "k9")
+	assert.NoError(err, "should check the missing document")
+	assert.False(ok, "should not find the missing document")
+}
+
+func TestInsertOptions(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	srv := newDocServer()
+	dbh := newStandInDatabase(t, srv.handle)
+	res, err := Insert(ctx, dbh, "stock", map[string]interface{}{
+		"_key": "gel", "name": "gel", "count": 4,
+	}, &DocOptions{ReturnNew: true, WaitForSync: true})
+	assert.NoError(err, "should insert the document")
+	assert.Equal("gel", (*res.New)["name"], "should return the new document")
+	assert.Contains(srv.params[0], "waitForSync=true", "should wait for sync")
+	_, err = Insert(ctx, dbh, "stock", &stockItem{
+		DocumentMeta: driver.DocumentMeta{Key: "gel"}, Name: "gel",
+	}, nil)
+	assert.ErrorIs(err, ErrConflict, "should return conflict for duplicate key")
+	upd, err := Insert(ctx, dbh, "stock", &stockItem{
+		DocumentMeta: driver.DocumentMeta{Key: "gel"}, Name: "gel", Count: 8,
+	}, &DocOptions{OverwriteMode: driver.OverwriteModeReplace, ReturnNew: true})
+	assert.NoError(err, "should replace the existing document")
+	assert.Equal(8, (*upd.New).Count, "should return the replaced document")
+	assert.Equal(upd.Meta.Rev, (*upd.New).Rev, "should fill the DocumentMeta of the new document")
+	_, err = Insert(ctx, dbh, "stock", &stockItem{}, &DocOptions{OverwriteMode: "merge"})
+	assert.Error(err, "should return error for invalid overwrite mode")
+}
+
+func TestInsertManyAndReadMany(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	dbh := newStandInDatabase(t, newDocServer().handle)
+	results, err := InsertMany(ctx, dbh, "stock", []*stockItem{
+		{DocumentMeta: driver.DocumentMeta{Key: "gel"}, Name: "gel"},
+		{DocumentMeta: driver.DocumentMeta{Key: "tip"}, Name: "tip"},
+		{DocumentMeta: driver.DocumentMeta{Key: "gel"}, Name: "gel"},
+	}, &DocOptions{ReturnNew: true})
+	assert.Error(err, "should report the failed document")
+	assert.ErrorIs(err, ErrConflict, "should report the duplicate key")
+	assert.Len(results, 3, "should return a result per document")
+	assert.Equal("tip", (*results[1].New).Name, "should return the new documents")
+	assert.Nil(results[2], "should not return a result for the failed document")
+	items, err := ReadMany[stockItem](ctx, dbh, "stock", []string{"tip", "box", "gel"})
+	assert.ErrorIs(err, ErrNotFound, "should report the missing document")
+	assert.Len(items, 3, "should return a document per key")
+	assert.Equal("tip", items[0].Name, "should follow the order of the keys")
+	assert.Equal("gel", items[2].Key, "should fill the DocumentMeta")
+	assert.Empty(items[1].Name, "should leave the missing document empty")
+	err = RemoveMany(ctx, dbh, "stock", []string{"gel", "box"}, nil)
+	assert.ErrorIs(err, ErrNotFound, "should report the missing document")
+	ok, err := Exists(ctx, dbh, "stock", "gel")
+	assert.NoError(err, "should check the document")
+	assert.False(ok, "should remove the existing document")
+}
+
+func TestUpdateReplaceRemove(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	dbh := newStandInDatabase(t, newDocServer().handle)
+	_, err := Insert(ctx, dbh, "stock", &stockItem{
+		DocumentMeta: driver.DocumentMeta{Key: "gel"}, Name: "gel", Count: 4,
+	}, nil)
+	assert.NoError(err, "should insert the document")
+	upd, err := Update[stockItem](
+		ctx, dbh, "stock", "gel",
+		map[string]int{"count": 6},
+		&DocOptions{ReturnNew: true, ReturnOld: true},
+	)
+	assert.NoError(err, "should update the document")
+	assert.Equal(4, upd.Old.Count, "should return the old document")
+	assert.Equal(6, upd.New.Count, "should return the updated document")
+	assert.Equal("gel", upd.New.Name, "should keep the other attributes")
+	assert.Equal(upd.Old.Rev, upd.Meta.OldRev, "should return the previous revision")
+	rep, err := Replace(ctx, dbh, "stock", "gel", stockItem{Name: "tip"}, &DocOptions{ReturnNew: true})
+	assert.NoError(err, "should replace the document")
+	assert.Equal(0, rep.New.Count, "should replace all the attributes")
+	_, err = Update[stockItem](ctx, dbh, "stock", "box", map[string]int{"count": 1}, nil)
+	assert.ErrorIs(err, ErrNotFound, "should not update missing document")
+	rem, err := Remove[stockItem](ctx, dbh, "stock", "gel", &DocOptions{ReturnOld: true, ReturnNew: true})
+	assert.NoError(err, "should remove the document")
+	assert.Equal("tip", rem.Old.Name, "should return the removed document")
+	assert.Nil(rem.New, "should not return a new document")
+	_, err = Remove[stockItem](ctx, dbh, "stock", "gel", nil)
+	assert.ErrorIs(err, ErrNotFound, "should not remove missing document")
+}
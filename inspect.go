@@ -0,0 +1,278 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrNoConnection is returned for the operations that need the connection
+// of the client, when the Database was not created through a Session.
+var ErrNoConnection = errors.New("database has no client connection")
+
+// modification node types of the AQL abstract syntax tree
+var modificationNodes = map[string]bool{
+	"insert":  true,
+	"update":  true,
+	"replace": true,
+	"remove":  true,
+	"upsert":  true,
+}
+
+// QueryInfo is the result of the static analysis of an AQL query.
+type QueryInfo struct {
+	// Collections read by the query
+	ReadCollections []string
+	// Collections modified by the query
+	WriteCollections []string
+	// Names of the bind parameters the query requires, the collection
+	// bind parameters are prefixed with "@", i.e. "@collection"
+	BindVars []string
+	// Whether the query modifies any data
+	Modifies bool
+	// collection bind parameters, without the "@" prefix, by their usage
+	readParams  []string
+	writeParams []string
+}
+
+type parseResponse struct {
+	Collections []string   `json:"collections"`
+	BindVars    []string   `json:"bindVars"`
+	AST         []*astNode `json:"ast"`
+}
+
+type astNode struct {
+	Type     string     `json:"type"`
+	Name     string     `json:"name"`
+	SubNodes []*astNode `json:"subNodes"`
+}
+
+// Inspect parses the query on the server, without running it, and reports
+// the collections it reads and writes, the bind parameters it requires and
+// whether it modifies data. It uses the same server API as ValidateQ, so a
+// syntax error in the query is returned as an error.
+//
+// Collections given as bind parameters (@@collection) are only known by
+// their bind parameter, use ResolveCollections to get their names.
+func (d *Database) Inspect(query string) (*QueryInfo, error) {
+	return d.inspect(context.Background(), query)
+}
+
+func (d *Database) inspect(ctx context.Context, query string) (*QueryInfo, error) {
+	if d.conn == nil {
+		return nil, ErrNoConnection
+	}
+	req, err := d.conn.NewRequest(
+		"POST",
+		path.Join("_db", url.PathEscape(d.dbh.Name()), "_api/query"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating parse request %s", err)
+	}
+	if _, err := req.SetBody(map[string]string{"query": query}); err != nil {
+		return nil, fmt.Errorf("error in setting parse request body %s", err)
+	}
+	resp, err := d.conn.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error in parsing the query %s", err)
+	}
+	if err := resp.CheckStatus(200); err != nil {
+		return nil, fmt.Errorf("error in parsing the query %w", err)
+	}
+	var prs parseResponse
+	if err := resp.ParseBody("", &prs); err != nil {
+		return nil, fmt.Errorf("error in decoding parse response %s", err)
+	}
+
+	return newQueryInfo(&prs), nil
+}
+
+func newQueryInfo(prs *parseResponse) *QueryInfo {
+	writes := make(map[string]bool)
+	params := make(map[string]bool)
+	wparams := make(map[string]bool)
+	for _, node := range prs.AST {
+		walkAST(node, false, writes, params, wparams)
+	}
+	info := &QueryInfo{
+		ReadCollections:  make([]string, 0),
+		WriteCollections: make([]string, 0),
+		BindVars:         append(make([]string, 0), prs.BindVars...),
+		Modifies:         len(writes) > 0 || len(wparams) > 0,
+	}
+	for _, coll := range prs.Collections {
+		if writes[coll] {
+			info.WriteCollections = append(info.WriteCollections, coll)
+			continue
+		}
+		info.ReadCollections = append(info.ReadCollections, coll)
+	}
+	for name := range params {
+		if wparams[name] {
+			info.writeParams = append(info.writeParams, name)
+			continue
+		}
+		info.readParams = append(info.readParams, name)
+	}
+	sort.Strings(info.BindVars)
+	sort.Strings(info.readParams)
+	sort.Strings(info.writeParams)
+
+	return info
+}
+
+// walkAST collects the modified collections and the collection bind
+// parameters, a collection is modified if it is the target of a
+// modification node.
+func walkAST(
+	node *astNode,
+	target bool,
+	writes, params, wparams map[string]bool,
+) {
+	if node == nil {
+		return
+	}
+	switch node.Type {
+	case "collection":
+		if target {
+			writes[node.Name] = true
+		}
+	case "datasource parameter":
+		name := strings.TrimPrefix(node.Name, "@")
+		params[name] = true
+		if target {
+			wparams[name] = true
+		}
+	}
+	for _, sub := range node.SubNodes {
+		walkAST(sub, modificationNodes[node.Type], writes, params, wparams)
+	}
+}
+
+// CheckBindVars checks that the bind parameters are exactly the ones
+// required by the query, it reports the missing and the unused ones.
+func (qi *QueryInfo) CheckBindVars(bindVars map[string]interface{}) error {
+	required := make(map[string]bool)
+	missing := make([]string, 0)
+	for _, name := range qi.BindVars {
+		required[name] = true
+		if _, ok := bindVars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	extra := make([]string, 0)
+	for name := range bindVars {
+		if !required[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	switch {
+	case len(missing) > 0 && len(extra) > 0:
+		return fmt.Errorf(
+			"missing bind parameters %v and unused bind parameters %v",
+			missing, extra,
+		)
+	case len(missing) > 0:
+		return fmt.Errorf("missing bind parameters %v", missing)
+	case len(extra) > 0:
+		return fmt.Errorf("unused bind parameters %v", extra)
+	}
+
+	return nil
+}
+
+// ResolveCollections returns a copy of the QueryInfo where the collection
+// bind parameters are replaced by the collection names in bindVars.
+func (qi *QueryInfo) ResolveCollections(
+	bindVars map[string]interface{},
+) (*QueryInfo, error) {
+	resolved := &QueryInfo{
+		ReadCollections:  append(make([]string, 0), qi.ReadCollections...),
+		WriteCollections: append(make([]string, 0), qi.WriteCollections...),
+		BindVars:         qi.BindVars,
+		Modifies:         qi.Modifies,
+	}
+	for _, prm := range []struct {
+		names []string
+		colls *[]string
+	}{
+		{qi.readParams, &resolved.ReadCollections},
+		{qi.writeParams, &resolved.WriteCollections},
+	} {
+		for _, name := range prm.names {
+			coll, ok := bindVars["@"+name].(string)
+			if !ok {
+				return nil, fmt.Errorf(
+					"collection bind parameter @%s is not set to a name",
+					name,
+				)
+			}
+			*prm.colls = appendUnique(*prm.colls, coll)
+		}
+	}
+	resolved.ReadCollections = slices.DeleteFunc(
+		resolved.ReadCollections,
+		func(coll string) bool {
+			return slices.Contains(resolved.WriteCollections, coll)
+		},
+	)
+
+	return resolved, nil
+}
+
+// TransactionOptions derives the options of a transaction that runs the
+// query, with the read and the write collections of the query. The
+// collection bind parameters are resolved with bindVars.
+func (qi *QueryInfo) TransactionOptions(
+	bindVars map[string]interface{},
+) (*TransactionOptions, error) {
+	resolved, err := qi.ResolveCollections(bindVars)
+	if err != nil {
+		return nil, err
+	}
+	opts := DefaultTransactionOptions()
+	opts.ReadCollections = resolved.ReadCollections
+	opts.WriteCollections = resolved.WriteCollections
+
+	return opts, nil
+}
+
+// validateQuery validates the query, its bind parameters are only checked
+// if the session was created WithBindVarChecks and the database has a
+// connection.
+func (d *Database) validateQuery(
+	ctx context.Context,
+	query string,
+	bindVars map[string]interface{},
+) error {
+	if !d.checkBindVars || d.conn == nil {
+		if err := d.dbh.ValidateQuery(ctx, query); err != nil {
+			return fmt.Errorf("error in validating the query %s", err)
+		}
+
+		return nil
+	}
+	info, err := d.inspect(ctx, query)
+	if err != nil {
+		return fmt.Errorf("error in validating the query %s", err)
+	}
+	if err := info.CheckBindVars(bindVars); err != nil {
+		return fmt.Errorf("error in validating the query %s", err)
+	}
+
+	return nil
+}
+
+func appendUnique(list []string, item string) []string {
+	if slices.Contains(list, item) {
+		return list
+	}
+
+	return append(list, item)
+}
@@ -0,0 +1,195 @@
+package arangomanager
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+	driverhttp "github.com/arangodb/go-driver/http"
+	"github.com/stretchr/testify/require"
+)
+
+const standInDB = "standin"
+
+// parse response of
+//
+//	FOR u IN users
+//		FOR g IN @@groups
+//			FILTER u.group == g._key AND u.age > @age
+//			INSERT { user: u._key } INTO @@log
+//			UPDATE g WITH { seen: true } IN groups
+var parseBody = `{
+	"error": false,
+	"code": 200,
+	"parsed": true,
+	"collections": ["groups", "users"],
+	"bindVars": ["@log", "age", "@groups"],
+	"ast": [{"type": "root", "subNodes": [
+		{"type": "for", "subNodes": [
+			{"type": "variable", "name": "u"},
+			{"type": "collection", "name": "users"}
+		]},
+		{"type": "for", "subNodes": [
+			{"type": "variable", "name": "g"},
+			{"type": "datasource parameter", "name": "@groups"}
+		]},
+		{"type": "filter", "subNodes": [{"type": "logical and", "subNodes": [
+			{"type": "compare ==", "subNodes": []},
+			{"type": "compare >", "subNodes": [
+				{"type": "attribute access", "name": "age"},
+				{"type": "parameter", "name": "age"}
+			]}
+		]}]},
+		{"type": "insert", "subNodes": [
+			{"type": "no-op"},
+			{"type": "datasource parameter", "name": "@log"},
+			{"type": "object", "subNodes": []}
+		]},
+		{"type": "update", "subNodes": [
+			{"type": "no-op"},
+			{"type": "collection", "name": "groups"},
+			{"type": "reference", "name": "g"},
+			{"type": "object", "subNodes": []}
+		]}
+	]}]
+}`
+
+// newStandInDatabase returns a Database connected to a stand-in server
+// that answers the database lookup, all other requests are passed to the
+// handler.
+func newStandInDatabase(t *testing.T, handler http.HandlerFunc) *Database {
+	t.Helper()
+	dbh, err := newStandInSession(t, handler).DB(standInDB)
+	require.NoError(t, err, "should get the stand-in database")
+
+	return dbh
+}
+
+// newStandInSession returns a Session connected to a stand-in server like
+// newStandInDatabase.
+func newStandInSession(
+	t *testing.T,
+	handler http.HandlerFunc,
+	opts ...SessionOption,
+) *Session {
+	t.Helper()
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(wrt http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/_db/"+standInDB+"/_api/database/current" {
+				writeJSON(wrt, http.StatusOK, map[string]interface{}{
+					"error": false, "code": 200,
+					"result": map[string]interface{}{"name": standInDB, "id": "1"},
+				})
+
+				return
+			}
+			handler(wrt, req)
+		},
+	))
+	t.Cleanup(srv.Close)
+	conn, err := driverhttp.NewConnection(
+		driverhttp.ConnectionConfig{Endpoints: []string{srv.URL}},
+	)
+	assert.NoError(err, "should create connection to stand-in server")
+	client, err := driver.NewClient(driver.ClientConfig{Connection: conn})
+	assert.NoError(err, "should create client of stand-in server")
+
+	return NewSessionFromClient(client, opts...)
+}
+
+func writeJSON(wrt http.ResponseWriter, status int, body interface{}) {
+	wrt.Header().Set("Content-Type", "application/json")
+	wrt.WriteHeader(status)
+	_ = json.NewEncoder(wrt).Encode(body)
+}
+
+func parseHandler(wrt http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost ||
+		req.URL.Path != "/_db/"+standInDB+"/_api/query" {
+		writeJSON(wrt, http.StatusNotFound, map[string]interface{}{
+			"error": true, "code": 404, "errorNum": 404,
+			"errorMessage": "unexpected request " + req.URL.Path,
+		})
+
+		return
+	}
+	var body map[string]string
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	if body["query"] == "FOR" {
+		writeJSON(wrt, http.StatusBadRequest, map[string]interface{}{
+			"error": true, "code": 400, "errorNum": 1501,
+			"errorMessage": "syntax error, unexpected end of query string",
+		})
+
+		return
+	}
+	wrt.Header().Set("Content-Type", "application/json")
+	_, _ = wrt.Write([]byte(parseBody))
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	dbh := newStandInDatabase(t, parseHandler)
+	info, err := dbh.Inspect("FOR u IN users RETURN u")
+	assert.NoError(err, "should inspect the query")
+	assert.Equal([]string{"users"}, info.ReadCollections, "should match read collections")
+	assert.Equal([]string{"groups"}, info.WriteCollections, "should match write collections")
+	assert.Equal([]string{"@groups", "@log", "age"}, info.BindVars, "should match bind parameters")
+	assert.True(info.Modifies, "should report data modification")
+
+	bindVars := map[string]interface{}{
+		"@groups": "groups", "@log": "audit", "age": 20,
+	}
+	assert.NoError(info.CheckBindVars(bindVars), "should accept the bind parameters")
+	assert.EqualError(
+		info.CheckBindVars(map[string]interface{}{"age": 20, "limit": 10}),
+		"missing bind parameters [@groups @log] and unused bind parameters [limit]",
+		"should report missing and unused bind parameters",
+	)
+	opts, err := info.TransactionOptions(bindVars)
+	assert.NoError(err, "should derive transaction options")
+	assert.Equal([]string{"users"}, opts.ReadCollections, "should read the users")
+	assert.Equal(
+		[]string{"groups", "audit"},
+		opts.WriteCollections,
+		"should write the groups and the resolved log collection",
+	)
+	_, err = info.TransactionOptions(map[string]interface{}{"@groups": 1})
+	assert.Error(err, "should return error for unresolved collections")
+
+	_, err = dbh.Inspect("FOR")
+	assert.Error(err, "should return error for invalid query")
+	var aerr driver.ArangoError
+	assert.True(errors.As(err, &aerr), "should wrap the server error")
+	assert.Equal(1501, aerr.ErrorNum, "should match the parse error number")
+}
+
+func TestSearchRowsBindVars(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	dbh, err := newStandInSession(t, parseHandler, WithBindVarChecks()).DB(standInDB)
+	assert.NoError(err, "should get the database")
+	_, err = dbh.SearchRows("FOR u IN users RETURN u", map[string]interface{}{"age": 20})
+	assert.ErrorContains(err, "missing bind parameters", "should reject missing bind parameters")
+	err = dbh.Do("FOR u IN users RETURN u", map[string]interface{}{"limit": 1})
+	assert.ErrorContains(err, "unused bind parameters [limit]", "should reject extra bind parameters")
+	_, err = (&Database{dbh: dbh.dbh}).Inspect("FOR u IN users RETURN u")
+	assert.ErrorIs(err, ErrNoConnection, "should require the client connection")
+
+	requests := 0
+	dbh = newStandInDatabase(t, func(wrt http.ResponseWriter, req *http.Request) {
+		requests++
+		parseHandler(wrt, req)
+	})
+	_, err = dbh.SearchRows("FOR u IN users RETURN u", map[string]interface{}{"age": 20})
+	assert.NotContains(err.Error(), "bind parameters", "should not check the bind parameters by default")
+	assert.Equal(2, requests, "should validate and run the query")
+	err = dbh.Do("FOR u IN users RETURN u", map[string]interface{}{"limit": 1})
+	assert.NotContains(err.Error(), "bind parameters", "should not check the bind parameters by default")
+	assert.Equal(3, requests, "should run the data modification query without validating it")
+}
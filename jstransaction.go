@@ -0,0 +1,198 @@
+package arangomanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// ErrUnknownJSFunction is returned by JSRegistry for names that are not
+// registered.
+var ErrUnknownJSFunction = errors.New("unknown javascript function")
+
+// jsTransactionRequest is the body of a javascript transaction request, the
+// driver only accepts the parameters as an array.
+type jsTransactionRequest struct {
+	Action             string                        `json:"action"`
+	Params             interface{}                   `json:"params,omitempty"`
+	WaitForSync        bool                          `json:"waitForSync,omitempty"`
+	AllowImplicit      bool                          `json:"allowImplicit,omitempty"`
+	LockTimeout        int                           `json:"lockTimeout,omitempty"`
+	MaxTransactionSize int                           `json:"maxTransactionSize,omitempty"`
+	Collections        driver.TransactionCollections `json:"collections"`
+}
+
+// RunJSTransaction runs the javascript function fn on the server within a
+// transaction and returns its result as raw JSON. The params are passed as
+// the only argument of fn. DefaultTransactionOptions is used if opts is nil,
+// the options for retries and the idle timeout do not apply.
+//
+//	db.RunJSTransaction(ctx, `function(params) {
+//		const db = require('@arangodb').db
+//		return db._collection(params.collection).count()
+//	}`, map[string]string{"collection": "users"}, opts)
+func (d *Database) RunJSTransaction(
+	ctx context.Context,
+	fn string,
+	params interface{},
+	opts *TransactionOptions,
+) (json.RawMessage, error) {
+	if d.conn == nil {
+		return nil, ErrNoConnection
+	}
+	if opts == nil {
+		opts = DefaultTransactionOptions()
+	}
+	if err := validateTransactionOptions(opts); err != nil {
+		return nil, err
+	}
+	req, err := d.conn.NewRequest(
+		"POST",
+		path.Join("_db", url.PathEscape(d.dbh.Name()), "_api/transaction"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating transaction request %s", err)
+	}
+	if _, err := req.SetBody(&jsTransactionRequest{
+		Action:             fn,
+		Params:             params,
+		WaitForSync:        opts.WaitForSync,
+		AllowImplicit:      opts.AllowImplicit,
+		LockTimeout:        opts.LockTimeout,
+		MaxTransactionSize: opts.MaxTransactionSize,
+		Collections: driver.TransactionCollections{
+			Read:      opts.ReadCollections,
+			Write:     opts.WriteCollections,
+			Exclusive: opts.ExclusiveCollections,
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("error in setting transaction request body %s", err)
+	}
+	resp, err := d.conn.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error in running javascript transaction %w", err)
+	}
+	if err := resp.CheckStatus(200); err != nil {
+		return nil, fmt.Errorf("error in running javascript transaction %w", err)
+	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := resp.ParseBody("", &result); err != nil {
+		return nil, fmt.Errorf("error in decoding transaction response %s", err)
+	}
+
+	return result.Result, nil
+}
+
+// RunJSTransactionAs runs the javascript function like
+// Database.RunJSTransaction and decodes its result into T.
+func RunJSTransactionAs[T any](
+	ctx context.Context,
+	dbh *Database,
+	fn string,
+	params interface{},
+	opts *TransactionOptions,
+) (T, error) {
+	var result T
+	raw, err := dbh.RunJSTransaction(ctx, fn, params, opts)
+	if err != nil {
+		return result, err
+	}
+	if len(raw) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return result, fmt.Errorf(
+			"error in decoding javascript transaction result %s",
+			err,
+		)
+	}
+
+	return result, nil
+}
+
+// JSRegistry holds named javascript functions for RunJSTransaction, usually
+// loaded from files embedded in the binary.
+type JSRegistry struct {
+	fns map[string]string
+}
+
+// NewJSRegistry loads every .js file of the directory dir in fsys, the name
+// of a function is the name of its file without the extension.
+//
+//	//go:embed js/*.js
+//	var jsFiles embed.FS
+//
+//	registry, err := arangomanager.NewJSRegistry(jsFiles, "js")
+func NewJSRegistry(fsys fs.FS, dir string) (*JSRegistry, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error in reading javascript directory %s", err)
+	}
+	reg := &JSRegistry{fns: make(map[string]string)}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".js" {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"error in reading javascript file %s %s",
+				entry.Name(), err,
+			)
+		}
+		fn := strings.TrimSpace(string(content))
+		if fn == "" {
+			return nil, fmt.Errorf("javascript file %s is empty", entry.Name())
+		}
+		reg.fns[strings.TrimSuffix(entry.Name(), ".js")] = fn
+	}
+
+	return reg, nil
+}
+
+// Names returns the sorted names of the registered functions.
+func (r *JSRegistry) Names() []string {
+	names := make([]string, 0, len(r.fns))
+	for name := range r.fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Function returns the source of the named function.
+func (r *JSRegistry) Function(name string) (string, error) {
+	fn, ok := r.fns[name]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownJSFunction, name)
+	}
+
+	return fn, nil
+}
+
+// Run runs the named function with Database.RunJSTransaction.
+func (r *JSRegistry) Run(
+	ctx context.Context,
+	dbh *Database,
+	name string,
+	params interface{},
+	opts *TransactionOptions,
+) (json.RawMessage, error) {
+	fn, err := r.Function(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbh.RunJSTransaction(ctx, fn, params, opts)
+}
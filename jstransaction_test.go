@@ -0,0 +1,145 @@
+package arangomanager
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/js
+var jsFiles embed.FS
+
+// jsServer is a stand-in for the javascript transaction API, it records
+// the requests and returns the queued result.
+type jsServer struct {
+	mu       sync.Mutex
+	requests []map[string]interface{}
+	result   interface{}
+}
+
+func (srv *jsServer) handle(wrt http.ResponseWriter, req *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if req.Method != http.MethodPost ||
+		req.URL.Path != "/_db/"+standInDB+"/_api/transaction" {
+		writeJSON(wrt, http.StatusNotFound, map[string]interface{}{
+			"error": true, "code": 404, "errorNum": 404,
+			"errorMessage": "unexpected request " + req.URL.Path,
+		})
+
+		return
+	}
+	var body map[string]interface{}
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	srv.requests = append(srv.requests, body)
+	if body["action"] == "throw" {
+		writeJSON(wrt, http.StatusInternalServerError, map[string]interface{}{
+			"error": true, "code": 500, "errorNum": 1650,
+			"errorMessage": "transaction aborted",
+		})
+
+		return
+	}
+	writeJSON(wrt, http.StatusOK, map[string]interface{}{
+		"error": false, "code": 200, "result": srv.result,
+	})
+}
+
+func TestRunJSTransaction(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &jsServer{result: map[string]interface{}{"removed": 2}}
+	dbh := newStandInDatabase(t, srv.handle)
+	raw, err := dbh.RunJSTransaction(
+		context.Background(),
+		"function(params) { return params }",
+		map[string]string{"user": "42"},
+		&TransactionOptions{
+			ReadCollections:  []string{"users"},
+			WriteCollections: []string{"order"},
+			LockTimeout:      5,
+		},
+	)
+	assert.NoError(err, "should run the javascript transaction")
+	assert.JSONEq(`{"removed": 2}`, string(raw), "should return the raw result")
+	assert.Equal(
+		map[string]interface{}{
+			"action":      "function(params) { return params }",
+			"params":      map[string]interface{}{"user": "42"},
+			"lockTimeout": float64(5),
+			"collections": map[string]interface{}{
+				"read":  []interface{}{"users"},
+				"write": []interface{}{"order"},
+			},
+		},
+		srv.requests[0],
+		"should send the function, the parameters and the options",
+	)
+	_, err = dbh.RunJSTransaction(context.Background(), "throw", nil, nil)
+	assert.Error(err, "should return error for failed transaction")
+	var aerr driver.ArangoError
+	assert.ErrorAs(err, &aerr, "should wrap the server error")
+	assert.Equal(1650, aerr.ErrorNum, "should match the error number")
+	_, err = dbh.RunJSTransaction(
+		context.Background(), "function() {}", nil,
+		&TransactionOptions{LockTimeout: -1},
+	)
+	assert.Error(err, "should return error for invalid options")
+	assert.Len(srv.requests, 2, "should not send invalid options")
+}
+
+func TestRunJSTransactionAs(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &jsServer{result: map[string]interface{}{"removed": 2}}
+	dbh := newStandInDatabase(t, srv.handle)
+	type cascade struct {
+		Removed int `json:"removed"`
+	}
+	res, err := RunJSTransactionAs[cascade](
+		context.Background(), dbh, "function() {}", nil, nil,
+	)
+	assert.NoError(err, "should run the javascript transaction")
+	assert.Equal(2, res.Removed, "should decode the result")
+	_, err = RunJSTransactionAs[[]string](
+		context.Background(), dbh, "function() {}", nil, nil,
+	)
+	assert.Error(err, "should return error for mismatched result")
+	srv.result = nil
+	count, err := RunJSTransactionAs[int](
+		context.Background(), dbh, "function() {}", nil, nil,
+	)
+	assert.NoError(err, "should accept a null result")
+	assert.Zero(count, "should return the zero value for null result")
+}
+
+func TestJSRegistry(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	reg, err := NewJSRegistry(jsFiles, "testdata/js")
+	assert.NoError(err, "should load the javascript files")
+	assert.Equal([]string{"cascade_delete", "count"}, reg.Names(), "should register the .js files")
+	fn, err := reg.Function("count")
+	assert.NoError(err, "should get the function")
+	assert.Contains(fn, "db._collection(params.collection).count()", "should match the source")
+	_, err = reg.Function("truncate")
+	assert.ErrorIs(err, ErrUnknownJSFunction, "should return error for unknown function")
+
+	srv := &jsServer{result: 10}
+	dbh := newStandInDatabase(t, srv.handle)
+	raw, err := reg.Run(
+		context.Background(), dbh, "count",
+		map[string]string{"collection": "users"}, nil,
+	)
+	assert.NoError(err, "should run the registered function")
+	assert.Equal("10", string(raw), "should return the count")
+	assert.Equal(fn, srv.requests[0]["action"], "should send the registered function")
+	_, err = NewJSRegistry(jsFiles, "testdata/sql")
+	assert.Error(err, "should return error for missing directory")
+}
@@ -0,0 +1,209 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dictyBase/arangomanager"
+)
+
+const countVar = "groupSize"
+
+var aggregateNameRegxp = regexp.MustCompile(`^[A-Za-z]\w*$`)
+
+// Aggregate is an aggregate function computed for every group.
+type Aggregate struct {
+	// Name of the aggregated value in the Facet
+	Name string `validate:"required"`
+	// Aggregate function, one of count, min, max, sum, average or
+	// distinct (the number of distinct values)
+	Function string `validate:"required,oneof=count min max sum average distinct"`
+	// Field to aggregate, not needed for count
+	Field string `validate:"required_unless=Function count"`
+}
+
+// Aggregation is a container for the elements needed to build a faceting
+// query, i.e.
+//
+//	FOR doc IN @@collection
+//		FILTER ...
+//		COLLECT g0 = doc.species WITH COUNT INTO groupSize
+//		RETURN { group: { species: g0 }, count: groupSize, values: {} }
+//
+// or, with aggregates
+//
+//	COLLECT g0 = doc.species AGGREGATE groupSize = LENGTH(1), a0 = MIN(doc.age)
+type Aggregation struct {
+	// Name of the collection to loop over
+	Collection string `validate:"required"`
+	// The variable used for looping inside the collection
+	Doc string `validate:"required"`
+	// Map of filter and group fields to database fields
+	Fmap map[string]string `validate:"required,min=1"`
+	// Filter string as accepted by ParseFilterString, optional, it is
+	// rejected like the one of ListQuery unless it is entirely made of filters
+	Filter string
+	// Policy the filters have to conform to, optional
+	Policy *FilterPolicy
+	// Fields to group by, the whole filtered collection forms a single
+	// group if empty
+	GroupBy []string `validate:"dive,required"`
+	// Aggregates computed for every group, the number of documents in a
+	// group is always counted
+	Aggregates []*Aggregate `validate:"dive"`
+	// Sort the groups by their number of documents, largest first,
+	// otherwise they are sorted by the group values
+	SortByCount bool
+	// Maximum number of groups, unlimited if zero
+	Limit int `validate:"gte=0"`
+}
+
+// Facet is a group of documents returned by an aggregation query.
+type Facet struct {
+	// Values of the group by fields, keyed by field
+	Group map[string]interface{} `json:"group"`
+	// Number of documents in the group
+	Count int64 `json:"count"`
+	// Aggregated values, keyed by the name of the aggregate
+	Values map[string]interface{} `json:"values"`
+}
+
+// Build generates the AQL aggregation query along with its bind parameters.
+func (agg *Aggregation) Build() (*Statement, error) {
+	if err := agg.validate(); err != nil {
+		return nil, err
+	}
+	stmt := &Statement{
+		BindVars: map[string]interface{}{"@collection": agg.Collection},
+	}
+	filterStmt, err := filterStringStatement(
+		agg.Filter, agg.Fmap, agg.Doc, agg.Policy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	clauses := []string{
+		fmt.Sprintf("FOR %s IN @@collection", agg.Doc),
+		filterStmt,
+		agg.collectStatement(),
+	}
+	if agg.SortByCount {
+		clauses = append(clauses, fmt.Sprintf("SORT %s DESC", countVar))
+	}
+	if agg.Limit > 0 {
+		stmt.BindVars["limit"] = agg.Limit
+		clauses = append(clauses, "LIMIT @limit")
+	}
+	stmt.Query = joinClauses(append(clauses, agg.returnStatement()))
+
+	return stmt, nil
+}
+
+func (agg *Aggregation) validate() error {
+	if err := validate.Struct(agg); err != nil {
+		return fmt.Errorf("validation error in Aggregation: %w", err)
+	}
+	for _, field := range agg.GroupBy {
+		if !fieldRegxp.MatchString(field) {
+			return fmt.Errorf("invalid group field %q", field)
+		}
+		if _, ok := agg.Fmap[field]; !ok {
+			return fmt.Errorf("missing group field %s in field map", field)
+		}
+	}
+	names := make(map[string]bool)
+	for _, agt := range agg.Aggregates {
+		if !aggregateNameRegxp.MatchString(agt.Name) {
+			return fmt.Errorf("invalid aggregate name %q", agt.Name)
+		}
+		if names[agt.Name] {
+			return fmt.Errorf("duplicate aggregate name %s", agt.Name)
+		}
+		names[agt.Name] = true
+		if _, ok := agg.Fmap[agt.Field]; agt.Function != "count" && !ok {
+			return fmt.Errorf("missing aggregate field %s in field map", agt.Field)
+		}
+	}
+
+	return nil
+}
+
+func (agg *Aggregation) collectStatement() string {
+	groups := make([]string, 0, len(agg.GroupBy))
+	for idx, field := range agg.GroupBy {
+		groups = append(groups, fmt.Sprintf(
+			"g%d = %s.%s", idx, agg.Doc, agg.Fmap[field],
+		))
+	}
+	collect := "COLLECT"
+	if len(groups) > 0 {
+		collect = fmt.Sprintf("COLLECT %s", strings.Join(groups, ", "))
+	}
+	if len(agg.Aggregates) == 0 {
+		return fmt.Sprintf("%s WITH COUNT INTO %s", collect, countVar)
+	}
+	aggrs := []string{fmt.Sprintf("%s = LENGTH(1)", countVar)}
+	for idx, agt := range agg.Aggregates {
+		aggrs = append(aggrs, fmt.Sprintf("a%d = %s", idx, agg.aggregateExpr(agt)))
+	}
+
+	return fmt.Sprintf("%s AGGREGATE %s", collect, strings.Join(aggrs, ", "))
+}
+
+func (agg *Aggregation) aggregateExpr(agt *Aggregate) string {
+	if agt.Function == "count" {
+		return "LENGTH(1)"
+	}
+	fns := map[string]string{
+		"min":      "MIN",
+		"max":      "MAX",
+		"sum":      "SUM",
+		"average":  "AVERAGE",
+		"distinct": "COUNT_DISTINCT",
+	}
+
+	return fmt.Sprintf("%s(%s.%s)", fns[agt.Function], agg.Doc, agg.Fmap[agt.Field])
+}
+
+func (agg *Aggregation) returnStatement() string {
+	groups := make([]string, 0, len(agg.GroupBy))
+	for idx, field := range agg.GroupBy {
+		groups = append(groups, fmt.Sprintf("%s: g%d", field, idx))
+	}
+	values := make([]string, 0, len(agg.Aggregates))
+	for idx, agt := range agg.Aggregates {
+		values = append(values, fmt.Sprintf("%s: a%d", agt.Name, idx))
+	}
+
+	return fmt.Sprintf(
+		"RETURN { group: { %s }, count: %s, values: { %s } }",
+		strings.Join(groups, ", "), countVar,
+		strings.Join(values, ", "),
+	)
+}
+
+// Facets runs the statement built by Aggregation and decodes its groups.
+func Facets(
+	dbh *arangomanager.Database,
+	stmt *Statement,
+) ([]*Facet, error) {
+	facets := make([]*Facet, 0)
+	rs, err := dbh.SearchRows(stmt.Query, stmt.BindVars)
+	if err != nil {
+		return facets, err
+	}
+	if rs.IsEmpty() {
+		return facets, nil
+	}
+	defer func() { _ = rs.Close() }()
+	for rs.Scan() {
+		fct := &Facet{}
+		if err := rs.Read(fct); err != nil {
+			return facets, err
+		}
+		facets = append(facets, fct)
+	}
+
+	return facets, nil
+}
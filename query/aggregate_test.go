@@ -0,0 +1,138 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregationBuild(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	agg := &Aggregation{
+		Collection:  "names",
+		Doc:         "doc",
+		Fmap:        nmap,
+		Filter:      "gender==female",
+		GroupBy:     []string{"state"},
+		SortByCount: true,
+		Limit:       5,
+	}
+	stmt, err := agg.Build()
+	assert.NoError(err, "should build the aggregation")
+	assert.Equal(
+		`FOR doc IN @@collection
+	FILTER doc.gender == 'female'
+	COLLECT g0 = doc.contact.address.state WITH COUNT INTO groupSize
+	SORT groupSize DESC
+	LIMIT @limit
+	RETURN { group: { state: g0 }, count: groupSize, values: {  } }`,
+		stmt.Query,
+		"should count the documents of every group",
+	)
+	assert.Equal(
+		map[string]interface{}{"@collection": "names", "limit": 5},
+		stmt.BindVars,
+		"should match the bind parameters",
+	)
+	agg = &Aggregation{
+		Collection: "names",
+		Doc:        "doc",
+		Fmap:       nmap,
+		GroupBy:    []string{"gender", "state"},
+		Aggregates: []*Aggregate{
+			{Name: "first", Function: "min", Field: "birthday"},
+			{Name: "last", Function: "max", Field: "birthday"},
+			{Name: "regions", Function: "distinct", Field: "region"},
+		},
+	}
+	stmt, err = agg.Build()
+	assert.NoError(err, "should build the aggregation")
+	assert.Equal(
+		`FOR doc IN @@collection
+	COLLECT g0 = doc.gender, g1 = doc.contact.address.state `+
+			`AGGREGATE groupSize = LENGTH(1), a0 = MIN(doc.birthday), `+
+			`a1 = MAX(doc.birthday), a2 = COUNT_DISTINCT(doc.contact.region)
+	RETURN { group: { gender: g0, state: g1 }, count: groupSize, `+
+			`values: { first: a0, last: a1, regions: a2 } }`,
+		stmt.Query,
+		"should aggregate the documents of every group",
+	)
+}
+
+func TestAggregationErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for name, agg := range map[string]*Aggregation{
+		"unmapped group field": {GroupBy: []string{"age"}},
+		"unmapped aggregate field": {Aggregates: []*Aggregate{
+			{Name: "oldest", Function: "min", Field: "age"},
+		}},
+		"missing aggregate field": {Aggregates: []*Aggregate{
+			{Name: "oldest", Function: "min"},
+		}},
+		"invalid function": {Aggregates: []*Aggregate{
+			{Name: "oldest", Function: "median", Field: "birthday"},
+		}},
+		"invalid name": {Aggregates: []*Aggregate{
+			{Name: "first-born", Function: "min", Field: "birthday"},
+		}},
+		"duplicate name": {Aggregates: []*Aggregate{
+			{Name: "born", Function: "min", Field: "birthday"},
+			{Name: "born", Function: "max", Field: "birthday"},
+		}},
+		"invalid filter": {Filter: "age>20"},
+	} {
+		agg.Collection = "names"
+		agg.Doc = "doc"
+		agg.Fmap = nmap
+		_, err := agg.Build()
+		assert.Errorf(err, "should return error for %s", name)
+	}
+}
+
+func TestFacets(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	dbh, cstr := setupTestArango(assert)
+	defer cleanupAfterEach(assert, dbh)
+	docs := readNames(assert)
+	coll, err := dbh.Collection(cstr)
+	assert.NoError(err, "should get the test collection")
+	_, err = coll.ImportDocuments(
+		context.Background(),
+		docs,
+		&driver.ImportDocumentOptions{Complete: true},
+	)
+	assert.NoError(err, "should import the test data")
+	counts := make(map[string]int64)
+	for _, doc := range docs {
+		counts[doc["gender"].(string)]++
+	}
+	stmt, err := (&Aggregation{
+		Collection: cstr,
+		Doc:        "doc",
+		Fmap:       nmap,
+		GroupBy:    []string{"gender"},
+		Aggregates: []*Aggregate{
+			{Name: "total", Function: "count"},
+			{Name: "earliest", Function: "min", Field: "birthday"},
+		},
+	}).Build()
+	assert.NoError(err, "should build the aggregation")
+	facets, err := Facets(dbh, stmt)
+	assert.NoError(err, "should run the aggregation")
+	assert.Len(facets, len(counts), "should return a facet for every gender")
+	for _, fct := range facets {
+		gender := fct.Group["gender"].(string)
+		assert.Equal(counts[gender], fct.Count, "should count the documents")
+		assert.EqualValues(
+			counts[gender],
+			fct.Values["total"],
+			"should match the count aggregate",
+		)
+		assert.NotEmpty(fct.Values["earliest"], "should aggregate the birthdays")
+	}
+}
@@ -0,0 +1,117 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	arrayExpansion   = "[*]"
+	inlineArrTmpl    = "LENGTH(%s[* FILTER %s]) > 0"
+	inlineNoArrTmpl  = "LENGTH(%s[* FILTER %s]) == 0"
+	inlineMatchTmpl  = "CONTAINS(CURRENT, LOWER('%s'))"
+	inlineInTmpl     = "'%s' IN %s[*]"
+	inlineNotInTmpl  = "'%s' NOT IN %s[*]"
+	currentReference = "CURRENT"
+)
+
+// hasArrayPath checks if the database path expands an array of objects,
+// for example "phones[*].type".
+func hasArrayPath(path string) bool {
+	return strings.Contains(path, arrayExpansion)
+}
+
+// splitArrayPath splits the path at its first array expansion, i.e.
+// "contact.phones[*].type" into "contact.phones" and "type".
+func splitArrayPath(path string) (string, string) {
+	idx := strings.Index(path, arrayExpansion)
+	if idx == -1 {
+		return path, ""
+	}
+
+	return path[:idx], strings.TrimPrefix(path[idx+len(arrayExpansion):], ".")
+}
+
+// genInlineArrayExpr generates an inline AQL expression for a filter on a
+// path that expands arrays. The filter is applied to the rest of the path
+// on every array element and matches if any of the elements does, for
+// example "phones[*].type" with "==" generates
+//
+//	LENGTH(doc.phones[* FILTER CURRENT.type == 'mobile']) > 0
+//
+// Every further expansion in the path nests another inline filter.
+func genInlineArrayExpr(ref string, flt *Filter) (string, error) {
+	if !hasArrayPath(ref) {
+		return genCondition(ref, flt)
+	}
+	prefix, rest := splitArrayPath(ref)
+	cref := currentReference
+	if len(rest) > 0 {
+		cref = fmt.Sprintf("%s.%s", currentReference, rest)
+	}
+	inner, err := genInlineArrayExpr(cref, flt)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(inlineArrTmpl, prefix, inner), nil
+}
+
+// genCondition generates the AQL condition of a filter applied to the
+// given attribute reference.
+func genCondition(ref string, flt *Filter) (string, error) {
+	switch {
+	case hasArrayOperator(flt.Operator):
+		return genArrayCondition(ref, flt), nil
+	case hasDateOperator(flt.Operator):
+		if err := dateValidator(flt.Value); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf(
+			"%s %s DATE_ISO8601('%s')",
+			ref, getOperator(flt.Operator), flt.Value,
+		), nil
+	case hasOperator(flt.Operator):
+		return fmt.Sprintf(
+			"%s %s %s",
+			ref, getOperator(flt.Operator),
+			addQuoteToStrings(flt.Operator, flt.Value),
+		), nil
+	default:
+		return "", fmt.Errorf(
+			"unknown opertaor for parsing %s",
+			flt.Operator,
+		)
+	}
+}
+
+func genArrayCondition(ref string, flt *Filter) string {
+	var cond string
+	switch getArrayOpertaor(flt.Operator) {
+	case "==":
+		cond = fmt.Sprintf(inlineInTmpl, flt.Value, ref)
+	case "!=":
+		cond = fmt.Sprintf(inlineNotInTmpl, flt.Value, ref)
+	case "=~":
+		cond = fmt.Sprintf(
+			inlineArrTmpl, ref,
+			fmt.Sprintf(inlineMatchTmpl, flt.Value),
+		)
+	case "!~":
+		cond = fmt.Sprintf(
+			inlineNoArrTmpl, ref,
+			fmt.Sprintf(inlineMatchTmpl, flt.Value),
+		)
+	}
+
+	return cond
+}
+
+// hasInlineArrayFilter checks if the filter is generated as an inline
+// expression instead of a LET subquery. Only the paths expanding arrays of
+// objects and the @!~ operator are inline, the other array operators on
+// flat arrays keep their LET subqueries, named by the VarNamer.
+func hasInlineArrayFilter(path string, flt *Filter) bool {
+	return hasArrayPath(path) || getArrayOpertaor(flt.Operator) == "!~"
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var pmap = map[string]string{
+	"phone_type": "contact.phones[*].type",
+	"phone_tag":  "contact.phones[*].tags",
+	"phone_date": "contact.phones[*].added",
+	"area":       "contact.phones[*].numbers[*].area",
+	"sport":      "sports",
+}
+
+var phoneDoc = map[string]interface{}{
+	"contact": map[string]interface{}{
+		"phones": []interface{}{
+			map[string]interface{}{
+				"type":  "home",
+				"tags":  []interface{}{"primary"},
+				"added": "2019-05-10",
+				"numbers": []interface{}{
+					map[string]interface{}{"area": "310"},
+				},
+			},
+			map[string]interface{}{
+				"type":  "mobile",
+				"tags":  []interface{}{"work", "shared"},
+				"added": "2021-01-01",
+				"numbers": []interface{}{
+					map[string]interface{}{"area": "312"},
+					map[string]interface{}{"area": "773"},
+				},
+			},
+		},
+	},
+	"sports": []interface{}{"Basketball", "tennis"},
+}
+
+func TestArrayPathFilterStatement(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	cases := map[string]string{
+		"phone_type==mobile": "FILTER LENGTH(doc.contact.phones[* FILTER CURRENT.type == 'mobile']) > 0",
+		"phone_type=~mob":    "FILTER LENGTH(doc.contact.phones[* FILTER CURRENT.type =~ 'mob']) > 0",
+		"phone_tag@==work":   "FILTER LENGTH(doc.contact.phones[* FILTER 'work' IN CURRENT.tags[*]]) > 0",
+		"phone_tag@=~wor": "FILTER LENGTH(doc.contact.phones[* FILTER " +
+			"LENGTH(CURRENT.tags[* FILTER CONTAINS(CURRENT, LOWER('wor'))]) > 0]) > 0",
+		"phone_date$>=2020": "FILTER LENGTH(doc.contact.phones[* FILTER " +
+			"CURRENT.added >= DATE_ISO8601('2020')]) > 0",
+		"area==773": "FILTER LENGTH(doc.contact.phones[* FILTER " +
+			"LENGTH(CURRENT.numbers[* FILTER CURRENT.area == '773']) > 0]) > 0",
+		"sport@!~golf": "FILTER LENGTH(doc.sports[* FILTER CONTAINS(CURRENT, LOWER('golf'))]) == 0",
+		"phone_type==mobile;sport@!~golf": "FILTER LENGTH(doc.contact.phones[* FILTER CURRENT.type == 'mobile']) > 0\n" +
+			" AND LENGTH(doc.sports[* FILTER CONTAINS(CURRENT, LOWER('golf'))]) == 0",
+	}
+	for fstr, expected := range cases {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should not return any parse error")
+		stmt, err := GenAQLFilterStatement(
+			&StatementParameters{Fmap: pmap, Filters: filters, Doc: "doc"},
+		)
+		assert.NoError(err, "should not return error when generating AQL filter statement")
+		assert.Equalf(expected, stmt, "should match inline statement of %s", fstr)
+		assert.NotContains(stmt, "LET", "should not contain LET subquery")
+	}
+	filters, err := ParseFilterString("phone_date$>=yesterday")
+	assert.NoError(err, "should not return any parse error")
+	_, err = GenAQLFilterStatement(
+		&StatementParameters{Fmap: pmap, Filters: filters, Doc: "doc"},
+	)
+	assert.Error(err, "should return error for invalid date")
+}
+
+func TestQualifiedArrayPathFilterStatement(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	filters, err := ParseFilterString("phone_type==mobile,phone_type==work")
+	assert.NoError(err, "should not return any parse error")
+	stmt, err := GenQualifiedAQLFilterStatement(
+		map[string]string{"phone_type": "v.phones[*].type"},
+		filters,
+	)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	assert.Equal(
+		"FILTER  ( LENGTH(v.phones[* FILTER CURRENT.type == 'mobile']) > 0\n"+
+			" OR LENGTH(v.phones[* FILTER CURRENT.type == 'work']) > 0 ) ",
+		stmt,
+		"should match qualified inline statement",
+	)
+}
+
+func TestCompileArrayPath(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	cases := map[string]bool{
+		"phone_type==mobile":   true,
+		"phone_type==fax":      false,
+		"phone_type!=mobile":   true,
+		"phone_tag@==work":     true,
+		"phone_tag@==personal": false,
+		"phone_tag@=~SHAR":     true,
+		"phone_date$>=2020":    true,
+		"phone_date$>=2022":    false,
+		"area==773":            true,
+		"area==212":            false,
+		"sport@!~golf":         true,
+		"sport@!~ten":          false,
+		"sport@=~BASKET":       false,
+	}
+	for fstr, expected := range cases {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should not return any parse error")
+		pred, err := Compile(pmap, filters)
+		assert.NoError(err, "should compile the filters")
+		ok, err := pred(phoneDoc)
+		assert.NoError(err, "should evaluate the document")
+		assert.Equalf(expected, ok, "should evaluate filter %s", fstr)
+	}
+}
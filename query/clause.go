@@ -0,0 +1,27 @@
+package query
+
+// toConjunction groups the filters into the boolean expression they stand
+// for in the generated AQL statements, i.e. a conjunction (AND) of
+// disjunctions (OR). Consecutive filters combined with "," (OR) end up in
+// the same group, while ";" (AND) starts a new one, so that
+//
+//	a,b;c;d,e
+//
+// is grouped as
+//
+//	(a OR b) AND c AND (d OR e)
+//
+// The logic of the last filter is ignored.
+func toConjunction(filters []*Filter) [][]*Filter {
+	groups := make([][]*Filter, 0)
+	curr := make([]*Filter, 0)
+	for idx, flt := range filters {
+		curr = append(curr, flt)
+		if idx == len(filters)-1 || getLogic(flt.Logic) != "OR" {
+			groups = append(groups, curr)
+			curr = make([]*Filter, 0)
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,366 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const isoDateLayout = "2006-01-02T15:04:05.000Z"
+
+// Predicate reports whether a document matches the filters it was
+// compiled from.
+type Predicate func(doc any) (bool, error)
+
+type matcher func(doc interface{}) bool
+
+// Compile generates a Predicate that evaluates the filters in memory
+// against Go values, following the same semantics as the AQL statement of
+// GenAQLFilterStatement. The field map is expected to contain
+// non-qualified (dotted) attribute paths, for example
+//
+//	{
+//		"first_name": "name.first",
+//		"email": "contact.email"
+//	}
+//
+// Documents could be maps or structs, they are looked up by their JSON
+// representation, so the struct fields are matched by their json tags.
+// The values are compared according to the AQL type order (null, bool,
+// number, string, array, object), dates are compared as ISO 8601 strings
+// and the array operators match against the elements of array attributes.
+func Compile(fmap map[string]string, filters []*Filter) (Predicate, error) {
+	if err := validate.Struct(&AQLFilterParams{
+		Fmap:    fmap,
+		Filters: filters,
+	}); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if err := validateFilterFields(fmap, filters); err != nil {
+		return nil, err
+	}
+	groups := make([][]matcher, 0)
+	for _, grp := range toConjunction(filters) {
+		mtchs := make([]matcher, 0, len(grp))
+		for _, flt := range grp {
+			mtch, err := compileFilter(fmap[flt.Field], flt)
+			if err != nil {
+				return nil, err
+			}
+			mtchs = append(mtchs, mtch)
+		}
+		groups = append(groups, mtchs)
+	}
+
+	return func(doc any) (bool, error) {
+		row, err := toDocument(doc)
+		if err != nil {
+			return false, err
+		}
+
+		return matchConjunction(groups, row), nil
+	}, nil
+}
+
+func matchConjunction(groups [][]matcher, row interface{}) bool {
+	for _, grp := range groups {
+		matched := false
+		for _, mtch := range grp {
+			if mtch(row) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func compileFilter(path string, flt *Filter) (matcher, error) {
+	switch {
+	case hasArrayPath(path):
+		return arrayPathMatcher(path, flt)
+	case hasArrayOperator(flt.Operator):
+		return arrayMatcher(path, flt), nil
+	case hasDateOperator(flt.Operator):
+		date, err := toISODate(flt.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return compareMatcher(path, getOperator(flt.Operator), date), nil
+	case hasOperator(flt.Operator):
+		return operatorMatcher(path, flt)
+	default:
+		return nil, fmt.Errorf("unknown opertaor for parsing %s", flt.Operator)
+	}
+}
+
+func operatorMatcher(path string, flt *Filter) (matcher, error) {
+	opr := getOperator(flt.Operator)
+	switch opr {
+	case "=~", "!~":
+		rgx, err := regexp.Compile(flt.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s %s", flt.Value, err)
+		}
+
+		return func(doc interface{}) bool {
+			val, _ := lookupValue(doc, path)
+			return rgx.MatchString(toAQLString(val)) == (opr == "=~")
+		}, nil
+	case "==", "!=":
+		return compareMatcher(path, opr, flt.Value), nil
+	default:
+		num, err := strconv.ParseFloat(flt.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"value %s of operator %s is not a number",
+				flt.Value, flt.Operator,
+			)
+		}
+
+		return compareMatcher(path, opr, num), nil
+	}
+}
+
+func compareMatcher(path, opr string, value interface{}) matcher {
+	return func(doc interface{}) bool {
+		val, _ := lookupValue(doc, path)
+		cmp := compareAQL(val, value)
+		switch opr {
+		case "==":
+			return cmp == 0
+		case "!=":
+			return cmp != 0
+		case ">":
+			return cmp > 0
+		case "<":
+			return cmp < 0
+		case ">=":
+			return cmp >= 0
+		default:
+			return cmp <= 0
+		}
+	}
+}
+
+func arrayMatcher(path string, flt *Filter) matcher {
+	opr := getArrayOpertaor(flt.Operator)
+	elemMatch := func(elem interface{}) bool {
+		return compareAQL(elem, flt.Value) == 0
+	}
+	if opr == "=~" || opr == "!~" {
+		lower := strings.ToLower(flt.Value)
+		elemMatch = func(elem interface{}) bool {
+			return strings.Contains(toAQLString(elem), lower)
+		}
+	}
+
+	return func(doc interface{}) bool {
+		val, _ := lookupValue(doc, path)
+		elems, _ := val.([]interface{})
+		found := false
+		for _, elem := range elems {
+			if elemMatch(elem) {
+				found = true
+				break
+			}
+		}
+
+		return found == (opr == "==" || opr == "=~")
+	}
+}
+
+// arrayPathMatcher matches if any element of the expanded array matches
+// the rest of the path.
+func arrayPathMatcher(path string, flt *Filter) (matcher, error) {
+	prefix, rest := splitArrayPath(path)
+	inner, err := compileFilter(rest, flt)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(doc interface{}) bool {
+		val, _ := lookupValue(doc, prefix)
+		elems, _ := val.([]interface{})
+		for _, elem := range elems {
+			if inner(elem) {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+// toISODate converts a filter date value to the string returned by the
+// DATE_ISO8601 AQL function.
+func toISODate(value string) (string, error) {
+	if err := dateValidator(value); err != nil {
+		return "", err
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if tms, err := time.Parse(layout, value); err == nil {
+			return tms.UTC().Format(isoDateLayout), nil
+		}
+	}
+
+	return "", fmt.Errorf("could not parse date %s", value)
+}
+
+// toDocument converts a Go value to its generic JSON representation.
+func toDocument(doc any) (map[string]interface{}, error) {
+	ctn, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error in encoding document %s", err)
+	}
+	var row map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(ctn))
+	dec.UseNumber()
+	if err := dec.Decode(&row); err != nil {
+		return nil, fmt.Errorf("error in decoding document %s", err)
+	}
+
+	return row, nil
+}
+
+// aqlTypeRank returns the position of the type of a value in the AQL
+// type order.
+func aqlTypeRank(val interface{}) int {
+	switch val.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case json.Number, float64:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// compareAQL compares two JSON values following the AQL comparison rules,
+// returns a negative number if left is smaller, zero if they are equal and
+// a positive number otherwise.
+func compareAQL(left, right interface{}) int {
+	lrank, rrank := aqlTypeRank(left), aqlTypeRank(right)
+	if lrank != rrank {
+		return lrank - rrank
+	}
+	switch lval := left.(type) {
+	case nil:
+		return 0
+	case bool:
+		return compareBool(lval, right.(bool))
+	case json.Number, float64:
+		return compareNumber(toFloat(left), toFloat(right))
+	case string:
+		return strings.Compare(lval, right.(string))
+	case []interface{}:
+		return compareArray(lval, right.([]interface{}))
+	default:
+		return compareObject(left, right)
+	}
+}
+
+func compareBool(left, right bool) int {
+	switch {
+	case left == right:
+		return 0
+	case right:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareNumber(left, right float64) int {
+	switch {
+	case left < right:
+		return -1
+	case left > right:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareArray(left, right []interface{}) int {
+	for idx := 0; idx < len(left) || idx < len(right); idx++ {
+		var lval, rval interface{}
+		if idx < len(left) {
+			lval = left[idx]
+		}
+		if idx < len(right) {
+			rval = right[idx]
+		}
+		if cmp := compareAQL(lval, rval); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+func compareObject(left, right interface{}) int {
+	lobj, _ := left.(map[string]interface{})
+	robj, _ := right.(map[string]interface{})
+	keys := make([]string, 0, len(lobj)+len(robj))
+	for key := range lobj {
+		keys = append(keys, key)
+	}
+	for key := range robj {
+		if _, ok := lobj[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if cmp := compareAQL(lobj[key], robj[key]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+func toFloat(val interface{}) float64 {
+	switch num := val.(type) {
+	case json.Number:
+		flt, _ := num.Float64()
+		return flt
+	case float64:
+		return num
+	default:
+		return 0
+	}
+}
+
+// toAQLString converts a value to string the same way as the TO_STRING
+// AQL function.
+func toAQLString(val interface{}) string {
+	switch str := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return str
+	case json.Number:
+		return str.String()
+	default:
+		ctn, _ := json.Marshal(str)
+		return string(ctn)
+	}
+}
@@ -0,0 +1,201 @@
+package query
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/dictyBase/arangomanager"
+	"github.com/stretchr/testify/require"
+)
+
+// mapping of filters to the attributes of the documents in testdata/names.json
+var nmap = map[string]string{
+	"first_name":   "name.first",
+	"gender":       "gender",
+	"state":        "contact.address.state",
+	"region":       "contact.region",
+	"email":        "contact.email",
+	"likes":        "likes",
+	"birthday":     "birthday",
+	"member_since": "memberSince",
+}
+
+// expected number of matching documents in testdata/names.json
+var nameFilters = map[string]int{
+	"gender==female":                            15,
+	"state==CA,state==NY":                       4,
+	"likes@==chess":                             4,
+	"likes@!=chess":                             26,
+	"gender==female;birthday$>=1980":            4,
+	"gender==male;likes@==chess,likes@==boxing": 2,
+	"email@=~LASERNA":                           1,
+	"first_name=~J":                             6,
+	"first_name!~J;gender===male":               12,
+	"member_since$<2009":                        7,
+	// region is a string, which is always greater than a number in AQL
+	"region>500": 30,
+}
+
+type nameDoc struct {
+	Key  string `json:"_key,omitempty"`
+	Name struct {
+		First string `json:"first"`
+		Last  string `json:"last"`
+	} `json:"name"`
+	Gender   string `json:"gender"`
+	Birthday string `json:"birthday"`
+	Contact  struct {
+		Address struct {
+			State string `json:"state"`
+		} `json:"address"`
+		Email  []string `json:"email"`
+		Region string   `json:"region"`
+	} `json:"contact"`
+	Likes       []string `json:"likes"`
+	MemberSince string   `json:"memberSince"`
+}
+
+func readNames(assert *require.Assertions) []map[string]interface{} {
+	fhr, err := os.Open(filepath.Join("..", "testdata", "names.json"))
+	assert.NoError(err, "should open the test data file")
+	defer fhr.Close()
+	docs := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(fhr)
+	for scanner.Scan() {
+		var doc map[string]interface{}
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &doc), "should decode document")
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	docs := readNames(assert)
+	for fstr, count := range nameFilters {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should not return any parse error")
+		pred, err := Compile(nmap, filters)
+		assert.NoErrorf(err, "should compile filter %s", fstr)
+		matched := 0
+		for _, doc := range docs {
+			ok, err := pred(doc)
+			assert.NoError(err, "should evaluate the document")
+			if ok {
+				matched++
+			}
+		}
+		assert.Equalf(count, matched, "should match documents of filter %s", fstr)
+	}
+}
+
+func TestCompileStruct(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	doc := &nameDoc{Gender: "male", Likes: []string{"chess"}}
+	doc.Name.First = "Jasper"
+	filters, err := ParseFilterString("first_name==Jasper;likes@==chess")
+	assert.NoError(err, "should not return any parse error")
+	pred, err := Compile(nmap, filters)
+	assert.NoError(err, "should compile the filters")
+	ok, err := pred(doc)
+	assert.NoError(err, "should evaluate the struct")
+	assert.True(ok, "should match the struct by json tags")
+	ok, err = pred(&nameDoc{Gender: "male"})
+	assert.NoError(err, "should evaluate the struct")
+	assert.False(ok, "should not match the struct")
+	_, err = pred([]string{"not", "a", "document"})
+	assert.Error(err, "should return error for non document value")
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for name, filters := range map[string][]*Filter{
+		"unknown field":    {{Field: "age", Operator: "==", Value: "5"}},
+		"invalid regex":    {{Field: "gender", Operator: "=~", Value: "(male"}},
+		"invalid number":   {{Field: "region", Operator: ">", Value: "five"}},
+		"invalid date":     {{Field: "birthday", Operator: "$>", Value: "yesterday"}},
+		"invalid operator": {{Field: "gender", Operator: "<>", Value: "male"}},
+		"empty filters":    {},
+	} {
+		_, err := Compile(nmap, filters)
+		assert.Errorf(err, "should return error for %s", name)
+	}
+}
+
+func TestCompileAgreesWithAQL(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	dbh, cstr := setupTestArango(assert)
+	defer cleanupAfterEach(assert, dbh)
+	docs := readNames(assert)
+	coll, err := dbh.Collection(cstr)
+	assert.NoError(err, "should get the test collection")
+	_, err = coll.ImportDocuments(
+		context.Background(),
+		docs,
+		&driver.ImportDocumentOptions{Complete: true},
+	)
+	assert.NoError(err, "should import the test data")
+	all := readAllNames(assert, dbh, cstr, "")
+	for fstr := range nameFilters {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should not return any parse error")
+		stmt, err := GenAQLFilterStatement(
+			&StatementParameters{Fmap: nmap, Filters: filters, Doc: "doc"},
+		)
+		assert.NoError(err, "should generate the AQL filter statement")
+		pred, err := Compile(nmap, filters)
+		assert.NoError(err, "should compile the filters")
+		expected := make([]string, 0)
+		for _, doc := range all {
+			ok, err := pred(doc)
+			assert.NoError(err, "should evaluate the document")
+			if ok {
+				expected = append(expected, doc.Key)
+			}
+		}
+		actual := collectKeys(readAllNames(assert, dbh, cstr, stmt))
+		sort.Strings(expected)
+		assert.Equalf(expected, actual, "should agree with AQL for filter %s", fstr)
+	}
+}
+
+func readAllNames(
+	assert *require.Assertions,
+	dbh *arangomanager.Database,
+	coll, filter string,
+) []*nameDoc {
+	rs, err := dbh.SearchRows(
+		genFullStmt(filter, coll),
+		nil,
+	)
+	assert.NoError(err, "should run the search query")
+	docs := make([]*nameDoc, 0)
+	for rs.Scan() {
+		doc := &nameDoc{}
+		assert.NoError(rs.Read(doc), "should read the document")
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+func collectKeys(docs []*nameDoc) []string {
+	keys := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		keys = append(keys, doc.Key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,369 @@
+package query
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of a filter field, it decides the operators the
+// field could be filtered with.
+type FieldType string
+
+const (
+	// PlainField is filtered with the standard operators
+	PlainField FieldType = ""
+	// DateField is filtered with the date operators, i.e. $>
+	DateField FieldType = "date"
+	// ArrayField is filtered with the array operators, i.e. @==
+	ArrayField FieldType = "array"
+)
+
+// Field describes a filter and sort field of a structure.
+type Field struct {
+	// Name of the field in filter and sort strings
+	Name string
+	// Database path of the field, i.e. props.color
+	Path string
+	// Type of the field
+	Type FieldType
+	// Allowed filter operators, any operator of the type is allowed if
+	// empty
+	Operators []string
+	// The field could be used in sort strings
+	Sortable bool
+}
+
+// FieldMap is the set of filter and sort fields of a structure, as built
+// by FieldMapFor and FieldMapFromStruct.
+type FieldMap struct {
+	// Fields keyed by their names
+	Fields map[string]*Field
+}
+
+// operators of the names used in the filter option of the arango tag, for
+// every field type
+var tagOperators = map[FieldType]map[string][]string{
+	PlainField: {
+		"eq":    {"==", "==="},
+		"ne":    {"!="},
+		"gt":    {">"},
+		"gte":   {">="},
+		"lt":    {"<"},
+		"lte":   {"<="},
+		"regex": {"=~", "!~"},
+	},
+	DateField: {
+		"eq":  {"$=="},
+		"gt":  {"$>"},
+		"gte": {"$>="},
+		"lt":  {"$<"},
+		"lte": {"$<="},
+	},
+	ArrayField: {
+		"eq":    {"@=="},
+		"ne":    {"@!="},
+		"regex": {"@=~", "@!~"},
+	},
+}
+
+// database paths of untagged fields that are not filter and sort fields
+var skippedPaths = []string{"_rev", "_oldRev"}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FieldMapFor builds the field map of the structure T, see
+// FieldMapFromStruct.
+func FieldMapFor[T any]() (*FieldMap, error) {
+	return fieldMapFromType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// FieldMapFromStruct builds the field map of the structure, or of the
+// structure it points to, from the arango tags of its exported fields, i.e.
+//
+//	Color   string    `json:"color" arango:"props.color,filter=eq|regex,sort"`
+//	Created time.Time `json:"created_at" arango:",filter=gt|lt,sort"`
+//
+// The field is named by its json tag, or by its Go name without one, and
+// the first element of the arango tag is its database path, the name is
+// used if it is empty. The options of the tag are
+//
+//   - filter: The allowed filter operators, separated by |, out of eq, ne,
+//     gt, gte, lt, lte and regex.
+//   - sort: The field could be used in sort strings.
+//   - type: The type of the field, date or array. It defaults to date for
+//     time.Time fields and to array for slices.
+//
+// Fields without an arango tag are opt-in: they are not sortable and are
+// only filtered with the operators of their type other than regex, while
+// the _rev and _oldRev fields of driver.DocumentMeta are skipped. A tag
+// without a filter option allows every operator of the type. Fields tagged
+// with - in either tag are skipped. The fields of anonymous embedded structures are
+// included as if they were fields of the structure, while other structure
+// fields are nested, i.e. the city field of an address field is named
+// address_city with the database path address.city. The arango tag of a
+// structure field only sets the path its fields are nested in. Structures
+// with their own json encoding, such as time.Time, are single fields.
+func FieldMapFromStruct(v interface{}) (*FieldMap, error) {
+	if v == nil {
+		return nil, fmt.Errorf("nil value has no fields")
+	}
+
+	return fieldMapFromType(reflect.TypeOf(v))
+}
+
+// Fmap returns the map of field names to database paths as used by
+// StatementParameters and ListSchema.
+func (fmp *FieldMap) Fmap() map[string]string {
+	fmap := make(map[string]string, len(fmp.Fields))
+	for name, fld := range fmp.Fields {
+		fmap[name] = fld.Path
+	}
+
+	return fmap
+}
+
+// QualifiedFmap returns the map of field names to database paths qualified
+// by the loop variable, as used by GenQualifiedAQLFilterStatement.
+func (fmp *FieldMap) QualifiedFmap(doc string) map[string]string {
+	fmap := make(map[string]string, len(fmp.Fields))
+	for name, fld := range fmp.Fields {
+		fmap[name] = doc + "." + fld.Path
+	}
+
+	return fmap
+}
+
+// SortFields returns the sorted names of the sortable fields.
+func (fmp *FieldMap) SortFields() []string {
+	names := make([]string, 0)
+	for name, fld := range fmp.Fields {
+		if fld.Sortable {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Policy returns the filter policy allowing the operators of every field.
+func (fmp *FieldMap) Policy() *FilterPolicy {
+	policy := &FilterPolicy{Fields: make(map[string]*FieldPolicy)}
+	for name, fld := range fmp.Fields {
+		fpl := &FieldPolicy{
+			Operators:  fld.Operators,
+			AllowRegex: len(fld.Operators) == 0,
+		}
+		if len(fpl.Operators) == 0 && fld.Type != PlainField {
+			fpl.Operators = typeOperators(fld.Type)
+		}
+		for _, opt := range fld.Operators {
+			fpl.AllowRegex = fpl.AllowRegex || isRegexOperator(opt)
+		}
+		policy.Fields[name] = fpl
+	}
+
+	return policy
+}
+
+func fieldMapFromType(typ reflect.Type) (*FieldMap, error) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %s is not a structure", typ)
+	}
+	fmp := &FieldMap{Fields: make(map[string]*Field)}
+
+	return fmp, fmp.addStruct(typ, "", "", make(map[reflect.Type]bool))
+}
+
+// addStruct adds the fields of the structure, with the name and the path
+// prefixes of the enclosing structure field. The structures being added are
+// tracked in visiting to reject recursive ones.
+func (fmp *FieldMap) addStruct(
+	typ reflect.Type,
+	name, path string,
+	visiting map[reflect.Type]bool,
+) error {
+	if visiting[typ] {
+		return fmt.Errorf("recursive structure %s", typ)
+	}
+	visiting[typ] = true
+	defer delete(visiting, typ)
+	embedded := make([]reflect.Type, 0)
+	for idx := 0; idx < typ.NumField(); idx++ {
+		sfl := typ.Field(idx)
+		jname, _, _ := strings.Cut(sfl.Tag.Get("json"), ",")
+		atag, tagged := sfl.Tag.Lookup("arango")
+		if jname == "-" || atag == "-" {
+			continue
+		}
+		ftype := sfl.Type
+		if ftype.Kind() == reflect.Ptr {
+			ftype = ftype.Elem()
+		}
+		if sfl.Anonymous && len(jname) == 0 && !tagged &&
+			ftype.Kind() == reflect.Struct {
+			embedded = append(embedded, ftype)
+
+			continue
+		}
+		if !sfl.IsExported() {
+			continue
+		}
+		if len(jname) == 0 {
+			jname = sfl.Name
+		}
+		if !tagged && slices.Contains(skippedPaths, jname) {
+			continue
+		}
+		fld, err := parseFieldTag(jname, atag, ftype)
+		if err != nil {
+			return fmt.Errorf("error in field %s of %s %s", sfl.Name, typ, err)
+		}
+		if len(name) > 0 {
+			fld.Name = name + "_" + fld.Name
+			fld.Path = path + "." + fld.Path
+		}
+		if isNestedStruct(ftype) {
+			if strings.Contains(atag, ",") {
+				return fmt.Errorf(
+					"structure field %s of %s only takes a path in its tag",
+					sfl.Name, typ,
+				)
+			}
+			if err := fmp.addStruct(ftype, fld.Name, fld.Path, visiting); err != nil {
+				return err
+			}
+
+			continue
+		}
+		if !fieldRegxp.MatchString(fld.Name) {
+			return fmt.Errorf("invalid field name %q", fld.Name)
+		}
+		fmp.Fields[fld.Name] = fld
+	}
+	// the fields of the structure shadow the ones of embedded structures
+	for _, etype := range embedded {
+		emp := &FieldMap{Fields: make(map[string]*Field)}
+		if err := emp.addStruct(etype, name, path, visiting); err != nil {
+			return err
+		}
+		for fname, fld := range emp.Fields {
+			if _, ok := fmp.Fields[fname]; !ok {
+				fmp.Fields[fname] = fld
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseFieldTag parses the arango tag of a field named name.
+func parseFieldTag(name, tag string, typ reflect.Type) (*Field, error) {
+	elems := strings.Split(tag, ",")
+	fld := &Field{
+		Name: name,
+		Path: strings.TrimSpace(elems[0]),
+		Type: inferFieldType(typ),
+	}
+	if len(fld.Path) == 0 {
+		fld.Path = name
+	}
+	if len(tag) == 0 {
+		fld.Operators = typeOperators(fld.Type, "regex")
+
+		return fld, nil
+	}
+	var filter string
+	for _, opt := range elems[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch key {
+		case "sort":
+			fld.Sortable = true
+		case "filter":
+			filter = value
+		case "type":
+			if value != string(DateField) && value != string(ArrayField) {
+				return nil, fmt.Errorf("unknown field type %q", value)
+			}
+			fld.Type = FieldType(value)
+		default:
+			return nil, fmt.Errorf("unknown tag option %q", opt)
+		}
+	}
+	if len(filter) == 0 {
+		return fld, nil
+	}
+	for _, opname := range strings.Split(filter, "|") {
+		opts, ok := tagOperators[fld.Type][opname]
+		if !ok {
+			return nil, fmt.Errorf(
+				"filter %q is not allowed for %s type", opname, fieldTypeName(fld.Type),
+			)
+		}
+		for _, opt := range opts {
+			if !slices.Contains(fld.Operators, opt) {
+				fld.Operators = append(fld.Operators, opt)
+			}
+		}
+	}
+
+	return fld, nil
+}
+
+func inferFieldType(typ reflect.Type) FieldType {
+	switch {
+	case typ == timeType:
+		return DateField
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8,
+		typ.Kind() == reflect.Array:
+		return ArrayField
+	}
+
+	return PlainField
+}
+
+// isNestedStruct checks if the fields of the structure are mapped to
+// nested paths, structures with their own json encoding are single
+// fields.
+func isNestedStruct(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		return false
+	}
+	ptr := reflect.PointerTo(typ)
+
+	return !ptr.Implements(marshalerType) && !ptr.Implements(textType)
+}
+
+// typeOperators returns the sorted operators of the field type, without
+// the ones of the excluded tag names.
+func typeOperators(ftype FieldType, exclude ...string) []string {
+	opts := make([]string, 0)
+	for name, names := range tagOperators[ftype] {
+		if !slices.Contains(exclude, name) {
+			opts = append(opts, names...)
+		}
+	}
+	slices.Sort(opts)
+
+	return opts
+}
+
+func fieldTypeName(ftype FieldType) string {
+	if ftype == PlainField {
+		return "plain"
+	}
+
+	return string(ftype)
+}
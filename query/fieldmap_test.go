@@ -0,0 +1,154 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City    string `json:"city" arango:",filter=eq,sort"`
+	Country string `json:"country"`
+}
+
+type customer struct {
+	driver.DocumentMeta
+	Email    string    `json:"email" arango:",filter=eq|ne"`
+	Label    string    `json:"label" arango:"props.label,filter=regex,sort"`
+	Joined   time.Time `json:"joined" arango:"created_at,filter=gt|lt,sort"`
+	Tags     []string  `json:"tags"`
+	Address  address   `json:"address" arango:"location"`
+	Billing  *address  `json:"billing"`
+	Secret   string    `json:"-"`
+	Internal string    `json:"internal" arango:"-"`
+	Rank     int
+	note     string
+}
+
+func TestFieldMapFor(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	fmp, err := FieldMapFor[customer]()
+	assert.NoError(err, "should build the field map")
+	assert.Equal(map[string]string{
+		"_key":            "_key",
+		"_id":             "_id",
+		"email":           "email",
+		"label":           "props.label",
+		"joined":          "created_at",
+		"tags":            "tags",
+		"address_city":    "location.city",
+		"address_country": "location.country",
+		"billing_city":    "billing.city",
+		"billing_country": "billing.country",
+		"Rank":            "Rank",
+	}, fmp.Fmap(), "should map the fields to their database paths")
+	assert.Equal(
+		[]string{"==", "===", "!="},
+		fmp.Fields["email"].Operators,
+		"should map the filter names to operators",
+	)
+	assert.Equal(DateField, fmp.Fields["joined"].Type, "should infer the date type")
+	assert.Equal(
+		[]string{"$>", "$<"},
+		fmp.Fields["joined"].Operators,
+		"should use the date operators",
+	)
+	assert.Equal(ArrayField, fmp.Fields["tags"].Type, "should infer the array type")
+	assert.Equal(
+		[]string{"!=", "<", "<=", "==", "===", ">", ">="},
+		fmp.Fields["Rank"].Operators,
+		"should allow the operators other than regex for untagged fields",
+	)
+	assert.Equal(
+		[]string{"address_city", "billing_city", "joined", "label"},
+		fmp.SortFields(),
+		"should include only the fields with the sort option",
+	)
+	assert.Equal(
+		"doc.location.city",
+		fmp.QualifiedFmap("doc")["address_city"],
+		"should qualify the database paths",
+	)
+	filters, err := ParseFilterString("address_city===kansas")
+	assert.NoError(err, "should parse the filter string")
+	stmt, err := GenQualifiedAQLFilterStatement(fmp.QualifiedFmap("doc"), filters)
+	assert.NoError(err, "should generate the filter statement")
+	assert.Equal("FILTER doc.location.city == 'kansas'", stmt, "should filter on the nested path")
+	other, err := FieldMapFromStruct(&customer{})
+	assert.NoError(err, "should build the field map of a pointer")
+	assert.Equal(fmp.Fmap(), other.Fmap(), "should match the field map of the type")
+}
+
+func TestFieldMapPolicy(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	fmp, err := FieldMapFor[customer]()
+	assert.NoError(err, "should build the field map")
+	policy := fmp.Policy()
+	for _, fstr := range []string{
+		"email==mahomes@gmail.com",
+		"label=~mahomes;joined$>2020",
+		"tags@==quarterback,address_city===kansas",
+		"Rank>=2",
+	} {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should parse the filter string")
+		assert.NoErrorf(policy.Check(filters), "should accept %s", fstr)
+	}
+	for _, fstr := range []string{
+		"email=~gmail",
+		"label==mahomes",
+		"joined==2020",
+		"tags==quarterback",
+		"secret==xyz",
+		"Rank=~2",
+		"tags@=~quarter",
+		"_rev==_r1",
+	} {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should parse the filter string")
+		assert.Errorf(policy.Check(filters), "should reject %s", fstr)
+	}
+}
+
+func TestFieldMapErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	_, err := FieldMapFromStruct("customer")
+	assert.Error(err, "should return error for a non structure")
+	_, err = FieldMapFromStruct(nil)
+	assert.Error(err, "should return error for nil")
+	_, err = FieldMapFromStruct(struct {
+		Joined time.Time `arango:",filter=regex"`
+	}{})
+	assert.ErrorContains(err, `filter "regex" is not allowed for date type`, "should reject operators of other types")
+	_, err = FieldMapFromStruct(struct {
+		Name string `arango:",filter=like"`
+	}{})
+	assert.Error(err, "should reject unknown filter names")
+	_, err = FieldMapFromStruct(struct {
+		Name string `arango:",type=number"`
+	}{})
+	assert.Error(err, "should reject unknown types")
+	_, err = FieldMapFromStruct(struct {
+		Name string `arango:",index"`
+	}{})
+	assert.Error(err, "should reject unknown options")
+	_, err = FieldMapFromStruct(struct {
+		Name string `json:"first-name"`
+	}{})
+	assert.Error(err, "should reject invalid field names")
+	_, err = FieldMapFromStruct(struct {
+		Address address `arango:"location,sort"`
+	}{})
+	assert.Error(err, "should reject options on structure fields")
+	type node struct {
+		Name   string `json:"name"`
+		Parent *node  `json:"parent"`
+	}
+	_, err = FieldMapFor[node]()
+	assert.ErrorContains(err, "recursive structure", "should reject recursive structures")
+}
@@ -0,0 +1,132 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	fieldRegxp = regexp.MustCompile(`^\w+$`)
+	valueRegxp = regexp.MustCompile(`^[\w-@.\s]+$`)
+)
+
+// JSONFilter is the JSON representation of a Filter. A list of filters is
+// serialized as a JSON array, for example
+//
+//	[
+//		{"field": "status", "operator": "==", "value": "active", "logic": "and"},
+//		{"field": "tag", "operator": "@==", "value": "remi", "logic": "or"},
+//		{"field": "tag", "operator": "@==", "value": "gwdi"}
+//	]
+//
+// which is equivalent to the filter string
+//
+//	status==active;tag@==remi,tag@==gwdi
+//
+// The logic is either "and" or "or" and combines the filter with the next
+// one, it is ignored for the last filter. The field, the operator and the
+// value follow the same rules as the filter string.
+type JSONFilter struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+	Logic    string `json:"logic,omitempty"`
+}
+
+// String returns the filter in the filter string syntax.
+func (f *Filter) String() string {
+	return fmt.Sprintf("%s%s%s%s", f.Field, f.Operator, f.Value, f.Logic)
+}
+
+// FormatFilterString converts a slice of Filter structures back to the
+// filter string syntax understood by ParseFilterString. Filters that are
+// followed by another one but have no logic are combined with AND (;),
+// the logic of the last filter is dropped.
+func FormatFilterString(filters []*Filter) string {
+	var bldr strings.Builder
+	for _, flt := range normalizeFilters(filters) {
+		bldr.WriteString(flt.String())
+	}
+
+	return bldr.String()
+}
+
+// FormatFilterJSON converts a slice of Filter structures to the JSON format
+// described in JSONFilter.
+func FormatFilterJSON(filters []*Filter) ([]byte, error) {
+	jfls := make([]*JSONFilter, 0, len(filters))
+	for _, flt := range normalizeFilters(filters) {
+		jfls = append(jfls, &JSONFilter{
+			Field:    flt.Field,
+			Operator: flt.Operator,
+			Value:    flt.Value,
+			Logic:    strings.ToLower(getLogic(flt.Logic)),
+		})
+	}
+	ctn, err := json.Marshal(jfls)
+	if err != nil {
+		return nil, fmt.Errorf("error in encoding filters to json %s", err)
+	}
+
+	return ctn, nil
+}
+
+// ParseFilterJSON parses filters in the JSON format described in
+// JSONFilter into a slice of Filter structures. Unlike ParseFilterString,
+// every filter element is validated and any invalid one results in an
+// error.
+func ParseFilterJSON(data []byte) ([]*Filter, error) {
+	var jfls []*JSONFilter
+	if err := json.Unmarshal(data, &jfls); err != nil {
+		return nil, fmt.Errorf("error in decoding filters from json %s", err)
+	}
+	filters := make([]*Filter, 0, len(jfls))
+	omap := getOperatorMap()
+	lmap := map[string]string{"": "", "and": ";", "or": ","}
+	for idx, jfl := range jfls {
+		if jfl == nil {
+			return nil, fmt.Errorf("filter %d is null", idx)
+		}
+		if !fieldRegxp.MatchString(jfl.Field) {
+			return nil, fmt.Errorf("filter %d has invalid field %q", idx, jfl.Field)
+		}
+		if _, ok := omap[jfl.Operator]; !ok {
+			return nil, fmt.Errorf("filter operator %s not allowed", jfl.Operator)
+		}
+		if !valueRegxp.MatchString(jfl.Value) {
+			return nil, fmt.Errorf("filter %d has invalid value %q", idx, jfl.Value)
+		}
+		logic, ok := lmap[strings.ToLower(jfl.Logic)]
+		if !ok {
+			return nil, fmt.Errorf("filter %d has invalid logic %q", idx, jfl.Logic)
+		}
+		filters = append(filters, &Filter{
+			Field:    jfl.Field,
+			Operator: jfl.Operator,
+			Value:    jfl.Value,
+			Logic:    logic,
+		})
+	}
+
+	return normalizeFilters(filters), nil
+}
+
+// normalizeFilters returns a copy of the filters where every filter except
+// the last one has a logic, AND being the default.
+func normalizeFilters(filters []*Filter) []*Filter {
+	normalized := make([]*Filter, 0, len(filters))
+	for idx, flt := range filters {
+		nflt := *flt
+		switch {
+		case idx == len(filters)-1:
+			nflt.Logic = ""
+		case len(getLogic(nflt.Logic)) == 0:
+			nflt.Logic = ";"
+		}
+		normalized = append(normalized, &nflt)
+	}
+
+	return normalized
+}
@@ -0,0 +1,116 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var filterStrings = []string{
+	"sport===football;email===mahomes@gmail.com",
+	"ontology!~dicty annotation;tag=~logicx",
+	"summary===bhokchoi;ontology===dicty_strain_property;tag===general strain,tag===REMI-seq",
+	"created_at$==2019,created_at$>2018",
+	"created_at$<2019;created_at$<=2018;created_at$>=2020",
+	"sport@!=banana,sport@==apple",
+	"sport@=~banana;sport@==apple;",
+	"age>20;age<=40,age>=60",
+	"email===mahomes@gmail.com email==brees@gmail.com",
+}
+
+func TestFilterString(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	flt := &Filter{Field: "name", Operator: "=~", Value: "john", Logic: ","}
+	assert.Equal(flt.String(), "name=~john,", "should match the filter string")
+	fls := []*Filter{
+		{Field: "name", Operator: "==", Value: "john"},
+		{Field: "age", Operator: "<=", Value: "20", Logic: ","},
+		{Field: "age", Operator: ">", Value: "60", Logic: ","},
+	}
+	fstr := FormatFilterString(fls)
+	assert.Equal(
+		fstr,
+		"name==john;age<=20,age>60",
+		"should add the default logic and drop the trailing one",
+	)
+	parsed, err := ParseFilterString(fstr)
+	assert.NoError(err, "should not return any parse error")
+	assert.Len(parsed, 3, "should parse all the formatted filters")
+	assert.Equal(parsed[1].Operator, "<=", "should parse the <= operator")
+	assert.Empty(FormatFilterString(nil), "should format empty filters")
+}
+
+func TestFilterJSON(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	fls, err := ParseFilterString("status==active;tag@==remi,tag@==gwdi")
+	assert.NoError(err, "should not return any parse error")
+	ctn, err := FormatFilterJSON(fls)
+	assert.NoError(err, "should not return any error from formatting json")
+	assert.JSONEq(
+		`[
+			{"field": "status", "operator": "==", "value": "active", "logic": "and"},
+			{"field": "tag", "operator": "@==", "value": "remi", "logic": "or"},
+			{"field": "tag", "operator": "@==", "value": "gwdi"}
+		]`,
+		string(ctn),
+		"should match the json format",
+	)
+	jfls, err := ParseFilterJSON(ctn)
+	assert.NoError(err, "should not return any error from parsing json")
+	assert.Equal(fls, jfls, "should match the parsed filter string")
+	upper, err := ParseFilterJSON(
+		[]byte(`[{"field": "age", "operator": ">", "value": "5", "logic": "OR"}]`),
+	)
+	assert.NoError(err, "should accept logic in upper case")
+	assert.Empty(upper[0].Logic, "should drop the logic of the last filter")
+
+	for name, input := range map[string]string{
+		"malformed json":   `{"field": "age"}`,
+		"null filter":      `[null]`,
+		"invalid field":    `[{"field": "a.b", "operator": "==", "value": "x"}]`,
+		"invalid operator": `[{"field": "age", "operator": "=>", "value": "5"}]`,
+		"invalid value":    `[{"field": "name", "operator": "==", "value": "o'neil"}]`,
+		"empty value":      `[{"field": "name", "operator": "==", "value": ""}]`,
+		"invalid logic": `[{"field": "name", "operator": "==", "value": "x", "logic": "xor"},
+			{"field": "name", "operator": "==", "value": "y"}]`,
+	} {
+		_, err := ParseFilterJSON([]byte(input))
+		assert.Errorf(err, "should return error for %s", name)
+	}
+}
+
+// FuzzFilterStringRoundTrip checks that any parsed filter string formats
+// back to an equivalent filter string, and that formatting is idempotent
+// for both the string and the json formats.
+func FuzzFilterStringRoundTrip(f *testing.F) {
+	for _, fstr := range filterStrings {
+		f.Add(fstr)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		assert := require.New(t)
+		fls, err := ParseFilterString(input)
+		if err != nil || len(fls) == 0 {
+			return
+		}
+		fstr := FormatFilterString(fls)
+		reparsed, err := ParseFilterString(fstr)
+		assert.NoError(err, "should parse the formatted filter string")
+		assert.Equal(
+			normalizeFilters(fls),
+			reparsed,
+			"should parse to an equivalent expression",
+		)
+		assert.Equal(
+			fstr,
+			FormatFilterString(reparsed),
+			"should format idempotently",
+		)
+		ctn, err := FormatFilterJSON(reparsed)
+		assert.NoError(err, "should format filters to json")
+		jfls, err := ParseFilterJSON(ctn)
+		assert.NoError(err, "should parse the formatted json")
+		assert.Equal(reparsed, jfls, "should round trip through json")
+	})
+}
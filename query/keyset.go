@@ -0,0 +1,279 @@
+package query
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dictyBase/arangomanager/collection"
+)
+
+const (
+	keyField       = "_key"
+	tokenPartCount = 2
+	// keysetAttr is the attribute holding the keyset values of a row of a
+	// keyset paginated query, it is stripped before the row is decoded
+	keysetAttr = "_keyset"
+)
+
+// ErrInvalidPageToken is returned when a page token cannot be decoded, has
+// been tampered with or does not belong to the current sort order and
+// filter.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Keyset is a container for elements needed in keyset (cursor) pagination.
+// The documents are ordered by the given sort fields followed by the
+// document key, which makes the order total and every page continuation
+// unambiguous.
+type Keyset struct {
+	// Map of sort fields to database fields
+	Fmap map[string]string `validate:"required"`
+	// Slice of Sort structs, could be empty to page by the document key only
+	Sorts []*Sort `validate:"dive"`
+	// The variable used for looping inside the collection
+	Doc string `validate:"required"`
+	// Secret used for signing the page tokens
+	Secret []byte `validate:"required,min=16"`
+	// Filter string of the paginated query, the tokens are only valid for
+	// the same filter
+	Filter string
+}
+
+type pageToken struct {
+	Sort string `json:"s"`
+	// digest of the filter string
+	Filter string        `json:"f,omitempty"`
+	Values []interface{} `json:"v"`
+}
+
+// Validate checks the Keyset parameters along with the presence of all sort
+// fields in the field map.
+func (kst *Keyset) Validate() error {
+	if err := validate.Struct(kst); err != nil {
+		return fmt.Errorf("validation error in Keyset: %w", err)
+	}
+
+	return validateSortFields(kst.Fmap, kst.Sorts)
+}
+
+// SortStatement generates the AQL SORT statement of the keyset, the
+// document key is always appended as the last sort field.
+func (kst *Keyset) SortStatement() string {
+	clauses := collection.Map(kst.Sorts, func(s *Sort) string {
+		return fmt.Sprintf(
+			"%s.%s %s",
+			kst.Doc, kst.Fmap[s.Field], sortDirection(s),
+		)
+	})
+
+	return toSortStatement(
+		append(clauses, fmt.Sprintf("%s.%s ASC", kst.Doc, keyField)),
+	)
+}
+
+// ContinuationStatement generates the AQL FILTER statement that continues
+// the keyset right after the row with the given sort values, i.e. the
+// equivalent of
+//
+//	FILTER (doc.a, doc._key) > (@a, @key)
+//
+// expanded into comparisons that respect the direction of every sort field.
+// The values are expected in the sort order, with the document key as the
+// last element. The bind parameters referenced in the statement are
+// returned along with it.
+func (kst *Keyset) ContinuationStatement(
+	values []interface{},
+) (string, map[string]interface{}, error) {
+	paths := kst.paths()
+	if len(values) != len(paths) {
+		return "", nil, fmt.Errorf(
+			"expected %d keyset values, got %d",
+			len(paths), len(values),
+		)
+	}
+	bindVars := make(map[string]interface{})
+	terms := make([]string, 0, len(paths))
+	for idx, pth := range paths {
+		conds := make([]string, 0, idx+1)
+		for eidx := 0; eidx < idx; eidx++ {
+			conds = append(conds, fmt.Sprintf(
+				"%s.%s == @%s", kst.Doc, paths[eidx].path, keysetVar(eidx),
+			))
+		}
+		conds = append(conds, fmt.Sprintf(
+			"%s.%s %s @%s", kst.Doc, pth.path, pth.operator, keysetVar(idx),
+		))
+		terms = append(terms, fmt.Sprintf("(%s)", strings.Join(conds, " AND ")))
+		bindVars[keysetVar(idx)] = values[idx]
+	}
+
+	return fmt.Sprintf("FILTER %s", strings.Join(terms, "\n OR ")), bindVars, nil
+}
+
+// EncodeToken generates a signed page token from the last row of a page.
+// The row is expected to contain all the sort fields along with the
+// document key.
+func (kst *Keyset) EncodeToken(row map[string]interface{}) (string, error) {
+	values := make([]interface{}, 0, len(kst.Sorts)+1)
+	for _, pth := range kst.paths() {
+		val, ok := lookupPath(row, pth.path)
+		if !ok {
+			return "", fmt.Errorf("missing keyset field %s in row", pth.path)
+		}
+		values = append(values, val)
+	}
+
+	return kst.encodeValues(values)
+}
+
+// ReturnValuesStatement generates the AQL array of the keyset values of the
+// current document, in the order expected by ContinuationStatement.
+func (kst *Keyset) ReturnValuesStatement() string {
+	return fmt.Sprintf("[%s]", strings.Join(
+		collection.Map(kst.paths(), func(pth keysetPath) string {
+			return fmt.Sprintf("%s.%s", kst.Doc, pth.path)
+		}),
+		", ",
+	))
+}
+
+// encodeValues generates a signed page token from the keyset values of the
+// last row of a page.
+func (kst *Keyset) encodeValues(values []interface{}) (string, error) {
+	if len(values) != len(kst.Sorts)+1 {
+		return "", fmt.Errorf(
+			"expected %d keyset values, got %d", len(kst.Sorts)+1, len(values),
+		)
+	}
+	payload, err := json.Marshal(&pageToken{
+		Sort:   kst.sortSignature(),
+		Filter: kst.filterDigest(),
+		Values: values,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error in encoding page token %s", err)
+	}
+
+	return fmt.Sprintf(
+		"%s.%s",
+		base64.RawURLEncoding.EncodeToString(payload),
+		base64.RawURLEncoding.EncodeToString(kst.sign(payload)),
+	), nil
+}
+
+// DecodeToken verifies the signature of a page token and returns the keyset
+// values stored in it. An ErrInvalidPageToken error is returned if the
+// token was tampered with or was generated for a different sort order.
+func (kst *Keyset) DecodeToken(token string) ([]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != tokenPartCount {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidPageToken)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPageToken, err)
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPageToken, err)
+	}
+	if !hmac.Equal(mac, kst.sign(payload)) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidPageToken)
+	}
+	var ptk pageToken
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&ptk); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPageToken, err)
+	}
+	if ptk.Sort != kst.sortSignature() {
+		return nil, fmt.Errorf("%w: sort order mismatch", ErrInvalidPageToken)
+	}
+	if ptk.Filter != kst.filterDigest() {
+		return nil, fmt.Errorf("%w: filter mismatch", ErrInvalidPageToken)
+	}
+	if len(ptk.Values) != len(kst.Sorts)+1 {
+		return nil, fmt.Errorf("%w: unexpected number of values", ErrInvalidPageToken)
+	}
+
+	return ptk.Values, nil
+}
+
+type keysetPath struct {
+	path     string
+	operator string
+}
+
+func (kst *Keyset) paths() []keysetPath {
+	paths := collection.Map(kst.Sorts, func(s *Sort) keysetPath {
+		if s.Descending {
+			return keysetPath{path: kst.Fmap[s.Field], operator: "<"}
+		}
+
+		return keysetPath{path: kst.Fmap[s.Field], operator: ">"}
+	})
+
+	return append(paths, keysetPath{path: keyField, operator: ">"})
+}
+
+func (kst *Keyset) sortSignature() string {
+	return strings.Join(collection.Map(kst.Sorts, func(s *Sort) string {
+		if s.Descending {
+			return "-" + s.Field
+		}
+
+		return s.Field
+	}), ",")
+}
+
+// filterDigest returns a short digest of the filter string, empty for
+// queries without a filter.
+func (kst *Keyset) filterDigest() string {
+	if len(kst.Filter) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(kst.Filter))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func (kst *Keyset) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, kst.Secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func keysetVar(idx int) string {
+	return fmt.Sprintf("keyset%d", idx)
+}
+
+// lookupPath returns the value of a dotted attribute path from a document.
+func lookupPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	return lookupValue(doc, path)
+}
+
+// lookupValue returns the value of a dotted attribute path from a JSON
+// value, the value itself is returned for an empty path.
+func lookupValue(doc interface{}, path string) (interface{}, bool) {
+	if len(path) == 0 {
+		return doc, true
+	}
+	curr := doc
+	for _, attr := range strings.Split(path, ".") {
+		obj, ok := curr.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if curr, ok = obj[attr]; !ok {
+			return nil, false
+		}
+	}
+
+	return curr, true
+}
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var tokenSecret = []byte("0123456789abcdef")
+
+func testKeyset() *Keyset {
+	return &Keyset{
+		Fmap: map[string]string{
+			"created_at": "created_at",
+			"first_name": "name.first",
+		},
+		Sorts: []*Sort{
+			{Field: "created_at", Descending: true},
+			{Field: "first_name"},
+		},
+		Doc:    "doc",
+		Secret: tokenSecret,
+	}
+}
+
+func TestKeysetStatements(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	kst := testKeyset()
+	assert.NoError(kst.Validate(), "should not return any validation error")
+	assert.Equal(
+		kst.SortStatement(),
+		"SORT doc.created_at DESC, doc.name.first ASC, doc._key ASC",
+		"should append the document key to the sort statement",
+	)
+	stmt, bindVars, err := kst.ContinuationStatement(
+		[]interface{}{"2020-01-01", "Lue", "1234"},
+	)
+	assert.NoError(err, "should not return any error from continuation")
+	assert.Equal(
+		stmt,
+		"FILTER (doc.created_at < @keyset0)\n"+
+			" OR (doc.created_at == @keyset0 AND doc.name.first > @keyset1)\n"+
+			" OR (doc.created_at == @keyset0 AND doc.name.first == @keyset1 AND doc._key > @keyset2)",
+		"should match the continuation statement",
+	)
+	assert.Equal(
+		bindVars,
+		map[string]interface{}{
+			"keyset0": "2020-01-01",
+			"keyset1": "Lue",
+			"keyset2": "1234",
+		},
+		"should match the continuation bind parameters",
+	)
+	_, _, err = kst.ContinuationStatement([]interface{}{"2020-01-01"})
+	assert.Error(err, "should return error for missing values")
+	invalid := testKeyset()
+	invalid.Sorts = []*Sort{{Field: "birthday"}}
+	assert.Error(invalid.Validate(), "should return error for unknown sort field")
+	invalid.Secret = []byte("short")
+	assert.Error(invalid.Validate(), "should return error for short secret")
+}
+
+func TestKeysetToken(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	kst := testKeyset()
+	row := map[string]interface{}{
+		"_key":       "1234",
+		"created_at": "2020-01-01",
+		"name":       map[string]interface{}{"first": "Lue", "last": "Laserna"},
+		"likes":      []interface{}{"chatting"},
+		"age":        json.Number("42"),
+	}
+	token, err := kst.EncodeToken(row)
+	assert.NoError(err, "should not return any error from encoding token")
+	values, err := kst.DecodeToken(token)
+	assert.NoError(err, "should not return any error from decoding token")
+	assert.Equal(
+		values,
+		[]interface{}{"2020-01-01", "Lue", "1234"},
+		"should decode the keyset values",
+	)
+	_, err = kst.EncodeToken(map[string]interface{}{"_key": "1234"})
+	assert.Error(err, "should return error for row without sort fields")
+
+	parts := strings.Split(token, ".")
+	tampered := testKeyset()
+	tampered.Sorts = tampered.Sorts[1:]
+	forged, err := tampered.EncodeToken(row)
+	assert.NoError(err, "should not return any error from encoding token")
+	for name, tkn := range map[string]string{
+		"malformed":       "abc",
+		"bad encoding":    "!!!.???",
+		"swapped payload": strings.Split(forged, ".")[0] + "." + parts[1],
+		"other sort":      forged,
+	} {
+		_, err := kst.DecodeToken(tkn)
+		assert.ErrorIsf(err, ErrInvalidPageToken, "should reject %s token", name)
+	}
+	other := testKeyset()
+	other.Secret = []byte("fedcba9876543210")
+	_, err = other.DecodeToken(token)
+	assert.ErrorIs(err, ErrInvalidPageToken, "should reject token signed with another secret")
+}
+
+func TestListQueryKeyset(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	kst := testKeyset()
+	kst.Filter = "first_name=~L"
+	token, err := kst.EncodeToken(map[string]interface{}{
+		"_key":       "1234",
+		"created_at": "2020-01-01",
+		"name":       map[string]interface{}{"first": "Lue"},
+	})
+	assert.NoError(err, "should not return any error from encoding token")
+	lqr := &ListQuery{
+		Collection:  "users",
+		Doc:         "doc",
+		Fmap:        kst.Fmap,
+		Filter:      "first_name=~L",
+		Sort:        "-created_at,first_name",
+		Limit:       10,
+		TokenSecret: tokenSecret,
+	}
+	first, err := lqr.Build()
+	assert.NoError(err, "should not return any error when building first page")
+	assert.Equal(
+		first.Query,
+		"FOR doc IN @@collection\n\t"+
+			"FILTER doc.name.first =~ 'L'\n\t"+
+			"SORT doc.created_at DESC, doc.name.first ASC, doc._key ASC\n\t"+
+			"LIMIT @limit\n\t"+
+			"RETURN MERGE(doc, { _keyset: [doc.created_at, doc.name.first, doc._key] })",
+		"should match the first page query",
+	)
+	assert.NotContains(first.BindVars, "offset", "should not have offset")
+	lqr.PageToken = token
+	next, err := lqr.Build()
+	assert.NoError(err, "should not return any error when building next page")
+	assert.Contains(
+		next.Query,
+		"FILTER doc.name.first =~ 'L'\n\tFILTER (doc.created_at < @keyset0)",
+		"should add the continuation after the filter",
+	)
+	assert.Equal(next.BindVars["keyset2"], "1234", "should bind the document key")
+	lqr.Filter = "first_name=~M"
+	_, err = lqr.Build()
+	assert.ErrorIs(err, ErrInvalidPageToken, "should reject token of another filter")
+	lqr.Filter = "first_name=~L"
+	lqr.Sort = "first_name"
+	_, err = lqr.Build()
+	assert.ErrorIs(err, ErrInvalidPageToken, "should reject token of another sort order")
+	lqr.Cursor = 10
+	_, err = lqr.Build()
+	assert.Error(err, "should not combine cursor with keyset pagination")
+	_, err = (&ListQuery{
+		Collection: "users", Doc: "doc", Fmap: kst.Fmap,
+		Limit: 10, PageToken: token,
+	}).Build()
+	assert.Error(err, "should require token secret for page token")
+}
@@ -0,0 +1,256 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Statement is a ready to run AQL query along with its bind parameters.
+type Statement struct {
+	// The AQL query
+	Query string
+	// Bind parameters referenced in the query
+	BindVars map[string]interface{}
+	// keyset of the query, only set for keyset paginated queries
+	keyset *Keyset
+	// page size of the query
+	limit int
+}
+
+// ListQuery is a container for the elements needed to build a paginated
+// list query, i.e.
+//
+//	FOR doc IN @@collection
+//		FILTER ...
+//		SORT ...
+//		LIMIT @offset, @limit
+//		RETURN doc
+type ListQuery struct {
+	// Name of the collection to loop over
+	Collection string `validate:"required"`
+	// The variable used for looping inside the collection
+	Doc string `validate:"required"`
+	// Map of filter and sort fields to database fields
+	Fmap map[string]string `validate:"required,min=1"`
+	// Filter string as accepted by ParseFilterString, optional. Unlike
+	// ParseFilterString, Build rejects it unless it is entirely made of
+	// filters without a trailing logic operator
+	Filter string
+	// Policy the filters have to conform to, optional
+	Policy *FilterPolicy
+	// Sort string as accepted by ParseSortString, optional
+	Sort string
+	// Page size
+	Limit int `validate:"gte=1"`
+	// Number of documents to skip before the page starts, it cannot
+	// be combined with keyset pagination
+	Cursor int `validate:"gte=0"`
+	// Field mask of the fields to return as accepted by GenAQLProjection,
+	// the whole document is returned if empty
+	Projection []string
+	// Secret for signing page tokens, setting it switches the query
+	// to keyset pagination
+	TokenSecret []byte
+	// Page token of the previous page as returned by QueryAll or
+	// Resultset.NextPageToken, requires TokenSecret
+	PageToken string
+}
+
+// Build generates the AQL list query along with its bind parameters.
+// Any LET statement produced by the array filter operators is placed
+// right after the FOR loop, before the FILTER statement.
+//
+// If TokenSecret is set, the query is paginated by keyset instead of
+// offset. The documents are then always sorted by their keys after the
+// given sort fields and the page continues right after the row encoded
+// in the PageToken.
+func (lqr *ListQuery) Build() (*Statement, error) {
+	if err := lqr.validate(); err != nil {
+		return nil, err
+	}
+	stmt := &Statement{
+		BindVars: map[string]interface{}{
+			"@collection": lqr.Collection,
+			"limit":       lqr.Limit,
+		},
+		limit: lqr.Limit,
+	}
+	clauses := []string{fmt.Sprintf("FOR %s IN @@collection", lqr.Doc)}
+	filterStmt, err := lqr.filterStatement()
+	if err != nil {
+		return nil, err
+	}
+	clauses = append(clauses, filterStmt)
+	pageClauses, err := lqr.pageStatements(stmt)
+	if err != nil {
+		return nil, err
+	}
+	clauses = append(clauses, pageClauses...)
+	returnStmt, err := lqr.returnStatement(stmt)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Query = joinClauses(append(clauses, returnStmt))
+
+	return stmt, nil
+}
+
+func (lqr *ListQuery) validate() error {
+	if err := validate.Struct(lqr); err != nil {
+		return fmt.Errorf("validation error in ListQuery: %w", err)
+	}
+	if len(lqr.TokenSecret) == 0 && len(lqr.PageToken) > 0 {
+		return errors.New("page token requires a token secret")
+	}
+	if len(lqr.TokenSecret) > 0 && lqr.Cursor > 0 {
+		return errors.New("cursor cannot be combined with keyset pagination")
+	}
+
+	return nil
+}
+
+// pageStatements generates the SORT and LIMIT statements, along with the
+// keyset continuation for keyset paginated queries.
+func (lqr *ListQuery) pageStatements(stmt *Statement) ([]string, error) {
+	sorts, err := ParseSortString(lqr.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if len(lqr.TokenSecret) == 0 {
+		sortStmt, err := lqr.sortStatement(sorts)
+		if err != nil {
+			return nil, err
+		}
+		stmt.BindVars["offset"] = lqr.Cursor
+
+		return []string{sortStmt, "LIMIT @offset, @limit"}, nil
+	}
+	kst := &Keyset{
+		Fmap:   lqr.Fmap,
+		Sorts:  sorts,
+		Doc:    lqr.Doc,
+		Secret: lqr.TokenSecret,
+		Filter: lqr.Filter,
+	}
+	if err := kst.Validate(); err != nil {
+		return nil, err
+	}
+	stmt.keyset = kst
+	clauses := make([]string, 0)
+	if len(lqr.PageToken) > 0 {
+		values, err := kst.DecodeToken(lqr.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		contStmt, bindVars, err := kst.ContinuationStatement(values)
+		if err != nil {
+			return nil, err
+		}
+		for name, val := range bindVars {
+			stmt.BindVars[name] = val
+		}
+		clauses = append(clauses, contStmt)
+	}
+
+	return append(clauses, kst.SortStatement(), "LIMIT @limit"), nil
+}
+
+func (lqr *ListQuery) filterStatement() (string, error) {
+	return filterStringStatement(lqr.Filter, lqr.Fmap, lqr.Doc, lqr.Policy)
+}
+
+// filterStringStatement parses the filter string, checks it against the
+// optional policy and generates its FILTER statement. The values of the
+// comparison operators are checked even without a policy, as they are
+// written into the statement without quotes.
+func filterStringStatement(
+	fstr string,
+	fmap map[string]string,
+	doc string,
+	policy *FilterPolicy,
+) (string, error) {
+	if len(strings.TrimSpace(fstr)) == 0 {
+		return "", nil
+	}
+	filters, err := parseWholeFilterString(strings.TrimSpace(fstr))
+	if err != nil {
+		return "", err
+	}
+	if policy != nil {
+		if err := policy.Check(filters); err != nil {
+			return "", err
+		}
+	}
+	if err := validateFilterFields(fmap, filters); err != nil {
+		return "", err
+	}
+	for _, flt := range filters {
+		if err := numberValidator(flt); err != nil {
+			return "", err
+		}
+	}
+
+	return GenAQLFilterStatement(&StatementParameters{
+		Fmap:    fmap,
+		Filters: filters,
+		Doc:     doc,
+	})
+}
+
+func (lqr *ListQuery) sortStatement(sorts []*Sort) (string, error) {
+	if len(sorts) == 0 {
+		return "", nil
+	}
+
+	return GenAQLSortStatement(&SortParameters{
+		Fmap:  lqr.Fmap,
+		Sorts: sorts,
+		Doc:   lqr.Doc,
+	})
+}
+
+// returnStatement generates the RETURN statement, every row of a keyset
+// paginated query carries the keyset values of the document in an extra
+// attribute. The token of the next page is then made of the values the
+// database sorted on, as neither the projection nor the decoded document
+// might keep them as they are.
+func (lqr *ListQuery) returnStatement(stmt *Statement) (string, error) {
+	retStmt := fmt.Sprintf("RETURN %s", lqr.Doc)
+	if len(lqr.Projection) > 0 {
+		projStmt, projVars, err := GenAQLProjection(
+			lqr.Fmap, lqr.Projection, lqr.Doc,
+		)
+		if err != nil {
+			return "", err
+		}
+		for name, val := range projVars {
+			stmt.BindVars[name] = val
+		}
+		retStmt = projStmt
+	}
+	if stmt.keyset == nil {
+		return retStmt, nil
+	}
+
+	return fmt.Sprintf(
+		"RETURN MERGE(%s, { %s: %s })",
+		strings.TrimPrefix(retStmt, "RETURN "),
+		keysetAttr, stmt.keyset.ReturnValuesStatement(),
+	), nil
+}
+
+func joinClauses(clauses []string) string {
+	var bldr strings.Builder
+	for _, cls := range clauses {
+		if len(strings.TrimSpace(cls)) == 0 {
+			continue
+		}
+		if bldr.Len() > 0 {
+			bldr.WriteString("\n\t")
+		}
+		bldr.WriteString(strings.TrimSpace(cls))
+	}
+
+	return bldr.String()
+}
@@ -0,0 +1,133 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListQueryBuild(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, err := (&ListQuery{
+		Collection: "users",
+		Doc:        "doc",
+		Fmap:       fmap,
+		Filter:     "email===mahomes@gmail.com,email===brees@gmail.com",
+		Sort:       "-created_at",
+		Limit:      10,
+		Cursor:     20,
+	}).Build()
+	assert.NoError(err, "should not return any error when building list query")
+	assert.Equal(
+		stmt.Query,
+		"FOR doc IN @@collection\n\t"+
+			"FILTER  ( doc.email == 'mahomes@gmail.com'\n OR doc.email == 'brees@gmail.com' )\n\t"+
+			"SORT doc.created_at DESC\n\t"+
+			"LIMIT @offset, @limit\n\t"+
+			"RETURN doc",
+		"should match list query",
+	)
+	assert.Equal(
+		stmt.BindVars,
+		map[string]interface{}{
+			"@collection": "users",
+			"offset":      20,
+			"limit":       10,
+		},
+		"should match bind parameters",
+	)
+}
+
+func TestListQueryArrayFilter(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, err := (&ListQuery{
+		Collection: "users",
+		Doc:        "doc",
+		Fmap:       fmap,
+		Filter:     "sport@==basketball;label=~GWDI",
+		Limit:      5,
+		Projection: []string{"label", "email"},
+	}).Build()
+	assert.NoError(err, "should not return any error when building list query")
+	assert.Regexp(
+		`(?s)^FOR doc IN @@collection\s+LET \w+ = \(.+\)\s+FILTER LENGTH\(\w+\) > 0`,
+		stmt.Query,
+		"should place the LET statement between FOR and FILTER",
+	)
+	assert.Contains(
+		stmt.Query,
+		"LIMIT @offset, @limit\n\tRETURN KEEP(doc, @projection)",
+		"should return the projection",
+	)
+	assert.Equal(
+		stmt.BindVars["projection"],
+		[]string{"label", "email"},
+		"should have the projection in bind parameters",
+	)
+}
+
+func TestListQueryValidation(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, err := (&ListQuery{
+		Collection: "users",
+		Doc:        "doc",
+		Fmap:       fmap,
+		Limit:      5,
+	}).Build()
+	assert.NoError(err, "should build a list query without filter and sort")
+	assert.Equal(
+		stmt.Query,
+		"FOR doc IN @@collection\n\tLIMIT @offset, @limit\n\tRETURN doc",
+		"should match list query without filter and sort",
+	)
+	cases := map[string]*ListQuery{
+		"missing limit": {Collection: "users", Doc: "doc", Fmap: fmap},
+		"unknown filter field": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "name==john", Limit: 5,
+		},
+		"invalid filter string": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "xyz", Limit: 5,
+		},
+		"trailing and": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "email==john;", Limit: 5,
+		},
+		"trailing or": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "email==john,", Limit: 5,
+		},
+		"unparsable tail": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "email==john;garbage!!", Limit: 5,
+		},
+		"quote in value": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "email==jo'hn", Limit: 5,
+		},
+		"unparsable head": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Filter: "!!email==john", Limit: 5,
+		},
+		"unknown sort field": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Sort: "-name", Limit: 5,
+		},
+		"unknown projection field": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Projection: []string{"name"}, Limit: 5,
+		},
+		"negative cursor": {
+			Collection: "users", Doc: "doc", Fmap: fmap,
+			Cursor: -1, Limit: 5,
+		},
+	}
+	for name, lqr := range cases {
+		_, err := lqr.Build()
+		assert.Errorf(err, "should return error for %s", name)
+	}
+}
@@ -0,0 +1,47 @@
+package query
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// VarNamer generates the name of the LET variable for the array filter at
+// the given position of the filter slice. The name has to be a valid AQL
+// variable name and unique within the statement.
+type VarNamer func(idx int, flt *Filter) string
+
+// FilterOption configures the generation of AQL filter statements.
+type FilterOption func(*filterOptions)
+
+type filterOptions struct {
+	namer VarNamer
+}
+
+// WithVarNamer sets a custom generator for the names of the LET variables.
+func WithVarNamer(namer VarNamer) FilterOption {
+	return func(fopt *filterOptions) {
+		fopt.namer = namer
+	}
+}
+
+func newFilterOptions(opts []FilterOption) *filterOptions {
+	fopt := &filterOptions{namer: HashedVarNamer}
+	for _, opt := range opts {
+		opt(fopt)
+	}
+
+	return fopt
+}
+
+// HashedVarNamer is the default VarNamer, it names the variable after a
+// hash of the position and the content of the filter. The names are
+// therefore stable across calls, which keeps the generated statements
+// identical for identical filters.
+func HashedVarNamer(idx int, flt *Filter) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf(
+		"%d|%s|%s|%s",
+		idx, flt.Field, flt.Operator, flt.Value,
+	)))
+
+	return fmt.Sprintf("arr_%x", sum[:5])
+}
@@ -0,0 +1,64 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministicVarNames(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	filters, err := ParseFilterString("sport@==basketball;sport@=~foot")
+	assert.NoError(err, "should not return any parse error")
+	prms := &StatementParameters{Fmap: fmap, Filters: filters, Doc: "doc"}
+	first, err := GenAQLFilterStatement(prms)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	second, err := GenAQLFilterStatement(prms)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	assert.Equal(first, second, "should generate identical statements")
+	name0 := HashedVarNamer(0, filters[0])
+	name1 := HashedVarNamer(1, filters[1])
+	assert.NotEqual(name0, name1, "should generate distinct variable names")
+	assert.Regexp(`^arr_[0-9a-f]{10}$`, name0, "should be a valid AQL variable name")
+	assert.Contains(
+		first,
+		fmt.Sprintf("FILTER LENGTH(%s) > 0\n AND LENGTH(%s) > 0", name0, name1),
+		"should reference the hashed variables",
+	)
+	qfirst, err := GenQualifiedAQLFilterStatement(qmap, filters)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	qsecond, err := GenQualifiedAQLFilterStatement(qmap, filters)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	assert.Equal(qfirst, qsecond, "should generate identical qualified statements")
+}
+
+func TestCustomVarNamer(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	filters, err := ParseFilterString("sport@!=banana,sport@==apple")
+	assert.NoError(err, "should not return any parse error")
+	namer := WithVarNamer(func(idx int, _ *Filter) string {
+		return fmt.Sprintf("sport%d", idx)
+	})
+	stmt, err := GenAQLFilterStatement(
+		&StatementParameters{Fmap: fmap, Filters: filters, Doc: "doc"},
+		namer,
+	)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	assert.Contains(stmt, "LET sport0 = (", "should name the first variable")
+	assert.Contains(stmt, "LET sport1 = (", "should name the second variable")
+	assert.Contains(
+		stmt,
+		"FILTER  ( LENGTH(sport0) > 0\n OR LENGTH(sport1) > 0 ) ",
+		"should reference the custom variables",
+	)
+	qstmt, err := GenQualifiedAQLFilterStatement(qmap, filters, namer)
+	assert.NoError(err, "should not return error when generating AQL filter statement")
+	assert.Contains(
+		qstmt,
+		"FILTER  ( LENGTH(sport0) > 0\n OR LENGTH(sport1) > 0 ) ",
+		"should reference the custom variables",
+	)
+}
@@ -0,0 +1,98 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dictyBase/arangomanager"
+)
+
+// Page is a container for a page of documents returned by a list query.
+type Page[T any] struct {
+	// Documents of the page
+	Items []*T
+	// Token of the next page, it is empty for offset paginated queries
+	// and for the last page of keyset paginated queries
+	NextToken string
+}
+
+// QueryAll runs the statement built by ListQuery and reads all of its
+// documents. For keyset paginated statements, the token of the next page
+// is generated from the last document whenever the page is full.
+func QueryAll[T any](
+	dbh *arangomanager.Database,
+	stmt *Statement,
+) (*Page[T], error) {
+	return QueryAllContext[T](context.Background(), dbh, stmt)
+}
+
+// QueryAllContext is QueryAll with a context for the query.
+func QueryAllContext[T any](
+	ctx context.Context,
+	dbh *arangomanager.Database,
+	stmt *Statement,
+) (*Page[T], error) {
+	page := &Page[T]{Items: make([]*T, 0)}
+	rs, err := dbh.SearchRowsContext(ctx, stmt.Query, stmt.BindVars)
+	if err != nil {
+		return page, err
+	}
+	if rs.IsEmpty() {
+		return page, nil
+	}
+	defer func() { _ = rs.Close() }()
+	var values []interface{}
+	for rs.Scan() {
+		item := new(T)
+		if stmt.keyset != nil {
+			values, err = readKeysetRow(rs, item)
+		} else {
+			err = rs.Read(item)
+		}
+		if err != nil {
+			return page, err
+		}
+		page.Items = append(page.Items, item)
+	}
+	if stmt.keyset == nil || len(page.Items) < stmt.limit {
+		return page, nil
+	}
+	token, err := stmt.keyset.encodeValues(values)
+	if err != nil {
+		return page, fmt.Errorf("error in generating next page token %s", err)
+	}
+	page.NextToken = token
+
+	return page, nil
+}
+
+// readKeysetRow decodes a row of a keyset paginated query into item and
+// returns the keyset values it carries, the keyset attribute is not decoded
+// into item.
+func readKeysetRow(
+	rs *arangomanager.Resultset,
+	item interface{},
+) ([]interface{}, error) {
+	var row map[string]json.RawMessage
+	if err := rs.Read(&row); err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	dec := json.NewDecoder(bytes.NewReader(row[keysetAttr]))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("error in decoding keyset values %s", err)
+	}
+	delete(row, keysetAttr)
+	ctn, err := json.Marshal(row)
+	if err != nil {
+		return nil, fmt.Errorf("error in encoding row %s", err)
+	}
+	if err := json.Unmarshal(ctn, item); err != nil {
+		return nil, fmt.Errorf("error in decoding row %s", err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,175 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+	driverhttp "github.com/arangodb/go-driver/http"
+	"github.com/dictyBase/arangomanager"
+	"github.com/stretchr/testify/require"
+)
+
+var bindParamRe = regexp.MustCompile(`@@?\w+`)
+
+// newStandInDatabase returns a Database connected to a stand-in server
+// that answers every query with the rows.
+func newStandInDatabase(
+	t *testing.T,
+	rows []interface{},
+	queries *[]string,
+) *arangomanager.Database {
+	t.Helper()
+	assert := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(wrt http.ResponseWriter, req *http.Request) {
+			var body map[string]interface{}
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			res := map[string]interface{}{"error": false, "code": http.StatusOK}
+			switch strings.TrimPrefix(req.URL.Path, "/_db/standin/_api/") {
+			case "database/current":
+				res["result"] = map[string]interface{}{"name": "standin", "id": "1"}
+			case "query":
+				params := make([]string, 0)
+				for _, prm := range bindParamRe.FindAllString(body["query"].(string), -1) {
+					params = append(params, strings.TrimPrefix(prm, "@"))
+				}
+				res["parsed"], res["bindVars"] = true, params
+				res["collections"], res["ast"] = []string{}, []interface{}{}
+			case "cursor":
+				*queries = append(*queries, body["query"].(string))
+				res["code"], res["result"] = http.StatusCreated, rows
+				res["hasMore"], res["count"] = false, len(rows)
+			}
+			wrt.Header().Set("Content-Type", "application/json")
+			wrt.WriteHeader(res["code"].(int))
+			_ = json.NewEncoder(wrt).Encode(res)
+		},
+	))
+	t.Cleanup(srv.Close)
+	conn, err := driverhttp.NewConnection(
+		driverhttp.ConnectionConfig{Endpoints: []string{srv.URL}},
+	)
+	assert.NoError(err, "should create connection to stand-in server")
+	client, err := driver.NewClient(driver.ClientConfig{Connection: conn})
+	assert.NoError(err, "should create client of stand-in server")
+	dbh, err := arangomanager.NewSessionFromClient(client).DB("standin")
+	assert.NoError(err, "should get the stand-in database")
+
+	return dbh
+}
+
+func TestQueryAllProjectedKeyset(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	queries := make([]string, 0)
+	dbh := newStandInDatabase(t, []interface{}{
+		map[string]interface{}{
+			"created_at": "2020-01-02",
+			"_keyset":    []interface{}{"2020-01-02", "Lue", "1"},
+		},
+		map[string]interface{}{
+			"created_at": "2020-01-01",
+			"_keyset":    []interface{}{"2020-01-01", "Ada", "2"},
+		},
+	}, &queries)
+	schema := &ListSchema{
+		Collection:   "users",
+		Doc:          "doc",
+		Fmap:         testKeyset().Fmap,
+		DefaultLimit: 2,
+		MaxLimit:     10,
+		DefaultSort:  "-created_at,first_name",
+		Projection:   []string{"created_at"},
+		TokenSecret:  tokenSecret,
+	}
+	stmt, err := FromListRequest(&ListRequest{Filter: "first_name=~L"}, schema)
+	assert.NoError(err, "should build the projected keyset query")
+	page, err := QueryAllContext[map[string]interface{}](context.Background(), dbh, stmt)
+	assert.NoError(err, "should query the projected page")
+	assert.Equal(
+		"RETURN MERGE(KEEP(doc, @projection), { _keyset: [doc.created_at, doc.name.first, doc._key] })",
+		queries[0][strings.LastIndex(queries[0], "RETURN"):],
+		"should return the keyset values along with the projection",
+	)
+	assert.Len(page.Items, 2, "should return the rows of the page")
+	assert.Equal(
+		map[string]interface{}{"created_at": "2020-01-01"},
+		*page.Items[1],
+		"should strip the keyset values from the rows",
+	)
+	assert.NotEmpty(page.NextToken, "should return the token of the next page")
+	next, err := FromListRequest(
+		&ListRequest{Filter: "first_name=~L", Cursor: page.NextToken},
+		schema,
+	)
+	assert.NoError(err, "should build the next page")
+	assert.Equal("Ada", next.BindVars["keyset1"], "should continue after the last row")
+	_, err = FromListRequest(
+		&ListRequest{Filter: "first_name=~A", Cursor: page.NextToken},
+		schema,
+	)
+	assert.ErrorIs(err, ErrInvalidPageToken, "should reject the token for another filter")
+}
+
+func TestQueryAllKeysetValues(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	queries := make([]string, 0)
+	dbh := newStandInDatabase(t, []interface{}{
+		map[string]interface{}{
+			"name":       "Lue",
+			"created_at": "2020-01-02T10:00:00.750Z",
+			"_keyset":    []interface{}{"2020-01-02T10:00:00.750Z", "1"},
+		},
+		map[string]interface{}{
+			"name":       "Ada",
+			"created_at": "2020-01-02T10:00:00.500Z",
+			"_keyset":    []interface{}{"2020-01-02T10:00:00.500Z", "2"},
+		},
+	}, &queries)
+	schema := &ListSchema{
+		Collection:   "users",
+		Doc:          "doc",
+		Fmap:         testKeyset().Fmap,
+		DefaultLimit: 2,
+		MaxLimit:     10,
+		DefaultSort:  "-created_at",
+		TokenSecret:  tokenSecret,
+	}
+	stmt, err := FromListRequest(&ListRequest{}, schema)
+	assert.NoError(err, "should build the keyset query")
+	type user struct {
+		Created time.Time `json:"created_at"`
+	}
+	page, err := QueryAllContext[user](context.Background(), dbh, stmt)
+	assert.NoError(err, "should query a page of documents without keys")
+	assert.Equal(
+		"RETURN MERGE(doc, { _keyset: [doc.created_at, doc._key] })",
+		queries[0][strings.LastIndex(queries[0], "RETURN"):],
+		"should return the keyset values along with the document",
+	)
+	assert.Equal(int(500*time.Millisecond), page.Items[1].Created.Nanosecond(), "should decode the document")
+	next, err := FromListRequest(&ListRequest{Cursor: page.NextToken}, schema)
+	assert.NoError(err, "should build the next page")
+	assert.Equal(
+		"2020-01-02T10:00:00.500Z",
+		next.BindVars["keyset0"],
+		"should continue after the stored sort value",
+	)
+	assert.Equal("2", next.BindVars["keyset1"], "should continue after the stored key")
+
+	type name struct {
+		Name string `json:"name"`
+	}
+	npage, err := QueryAllContext[name](context.Background(), dbh, stmt)
+	assert.NoError(err, "should query a page of documents without the sort field")
+	assert.Equal("Ada", npage.Items[1].Name, "should decode the document")
+	assert.NotEmpty(npage.NextToken, "should return the token of the next page")
+}
@@ -0,0 +1,133 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FieldPolicy restricts the filters allowed on a field.
+type FieldPolicy struct {
+	// Allowed filter operators, i.e. "==", "$>" or "@==", any operator
+	// is allowed if empty. Regular expression operators additionally
+	// require AllowRegex.
+	Operators []string `validate:"dive,operator_validation"`
+	// Maximum length of the filter value, unlimited if zero
+	MaxValueLength int `validate:"gte=0"`
+	// Allow the regular expression operators =~ and !~
+	AllowRegex bool
+}
+
+// FilterPolicy restricts the filters accepted from untrusted input, i.e.
+// the filter string of a public API.
+type FilterPolicy struct {
+	// Map of filter fields to their policy, filters on any other field
+	// are rejected
+	Fields map[string]*FieldPolicy `validate:"required,min=1,dive,required"`
+	// Maximum number of filters, unlimited if zero
+	MaxClauses int `validate:"gte=0"`
+	// Maximum depth of the boolean expression the filters stand for,
+	// unlimited if zero. A single filter has a depth of one, filters
+	// combined only with AND or only with OR a depth of two and AND
+	// combined groups of OR a depth of three.
+	MaxDepth int `validate:"gte=0"`
+}
+
+// PolicyError is returned for filters that violate a FilterPolicy.
+type PolicyError struct {
+	// Field of the offending filter, empty for violations of the whole
+	// filter expression
+	Field string
+	// Description of the violation
+	Reason string
+}
+
+func (perr *PolicyError) Error() string {
+	if len(perr.Field) == 0 {
+		return fmt.Sprintf("filter policy violation: %s", perr.Reason)
+	}
+
+	return fmt.Sprintf(
+		"filter policy violation on field %s: %s",
+		perr.Field, perr.Reason,
+	)
+}
+
+// Check validates the filters against the policy and returns a
+// *PolicyError describing the first violation. Besides the fields and the
+// operators, it checks that the values of the comparison operators >, >=,
+// < and <= are numbers, as they are not quoted in the statements.
+func (fpl *FilterPolicy) Check(filters []*Filter) error {
+	if err := validate.Struct(fpl); err != nil {
+		return fmt.Errorf("validation error in FilterPolicy: %w", err)
+	}
+	if fpl.MaxClauses > 0 && len(filters) > fpl.MaxClauses {
+		return &PolicyError{Reason: fmt.Sprintf(
+			"%d filters exceed the maximum of %d",
+			len(filters), fpl.MaxClauses,
+		)}
+	}
+	if depth := expressionDepth(filters); fpl.MaxDepth > 0 && depth > fpl.MaxDepth {
+		return &PolicyError{Reason: fmt.Sprintf(
+			"expression depth %d exceeds the maximum of %d",
+			depth, fpl.MaxDepth,
+		)}
+	}
+	for _, flt := range filters {
+		if err := fpl.checkFilter(flt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (fpl *FilterPolicy) checkFilter(flt *Filter) error {
+	fpol, ok := fpl.Fields[flt.Field]
+	if !ok {
+		return &PolicyError{Field: flt.Field, Reason: "field is not filterable"}
+	}
+	if len(fpol.Operators) > 0 && !slices.Contains(fpol.Operators, flt.Operator) {
+		return &PolicyError{Field: flt.Field, Reason: fmt.Sprintf(
+			"operator %s is not allowed, allowed operators are %v",
+			flt.Operator, fpol.Operators,
+		)}
+	}
+	if isRegexOperator(flt.Operator) && !fpol.AllowRegex {
+		return &PolicyError{Field: flt.Field, Reason: fmt.Sprintf(
+			"regular expression operator %s is not allowed",
+			flt.Operator,
+		)}
+	}
+	if err := numberValidator(flt); err != nil {
+		return &PolicyError{Field: flt.Field, Reason: err.Error()}
+	}
+	if fpol.MaxValueLength > 0 && len(flt.Value) > fpol.MaxValueLength {
+		return &PolicyError{Field: flt.Field, Reason: fmt.Sprintf(
+			"value length %d exceeds the maximum of %d",
+			len(flt.Value), fpol.MaxValueLength,
+		)}
+	}
+
+	return nil
+}
+
+func isRegexOperator(opt string) bool {
+	return !hasArrayOperator(opt) &&
+		(getOperator(opt) == "=~" || getOperator(opt) == "!~")
+}
+
+// expressionDepth calculates the depth of the boolean expression tree of
+// the filters as grouped by toConjunction.
+func expressionDepth(filters []*Filter) int {
+	groups := toConjunction(filters)
+	switch {
+	case len(filters) == 0:
+		return 0
+	case len(filters) == 1:
+		return 1
+	case len(groups) == 1 || len(groups) == len(filters):
+		return 2
+	default:
+		return 3
+	}
+}
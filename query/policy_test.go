@@ -0,0 +1,91 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testPolicy() *FilterPolicy {
+	return &FilterPolicy{
+		Fields: map[string]*FieldPolicy{
+			"email":      {Operators: []string{"==", "!="}, MaxValueLength: 20},
+			"summary":    {AllowRegex: true},
+			"sport":      {Operators: []string{"@==", "@!="}},
+			"created_at": {Operators: []string{"$>", "$<", "=~"}},
+			"age":        {},
+		},
+		MaxClauses: 4,
+		MaxDepth:   2,
+	}
+}
+
+func TestFilterPolicyCheck(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for _, fstr := range []string{
+		"email==mahomes@gmail.com",
+		"summary=~shock;sport@==basketball",
+		"created_at$>2019,created_at$<2010,email!=brees@gmail.com",
+		"age>21;age<=-1.5e3",
+	} {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should not return any parse error")
+		assert.NoErrorf(testPolicy().Check(filters), "should accept %s", fstr)
+	}
+}
+
+func TestFilterPolicyViolations(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for fstr, msg := range map[string]string{
+		"label==dicty": "filter policy violation on field label: " +
+			"field is not filterable",
+		"email=~gmail": "filter policy violation on field email: " +
+			"operator =~ is not allowed, allowed operators are [== !=]",
+		"created_at=~2019": "filter policy violation on field created_at: " +
+			"regular expression operator =~ is not allowed",
+		"email==patrick.mahomes@gmail.com": "filter policy violation on field email: " +
+			"value length 25 exceeds the maximum of 20",
+		"summary=~a;summary=~b;summary=~c;summary=~d;summary=~e": "filter policy violation: " +
+			"5 filters exceed the maximum of 4",
+		"summary=~a,summary=~b;summary=~c": "filter policy violation: " +
+			"expression depth 3 exceeds the maximum of 2",
+		"age>abc": "filter policy violation on field age: " +
+			`value "abc" of operator > is not a number`,
+		"age<=1-2": "filter policy violation on field age: " +
+			`value "1-2" of operator <= is not a number`,
+	} {
+		filters, err := ParseFilterString(fstr)
+		assert.NoError(err, "should not return any parse error")
+		err = testPolicy().Check(filters)
+		var perr *PolicyError
+		assert.Truef(errors.As(err, &perr), "should return a policy error for %s", fstr)
+		assert.Equal(msg, err.Error(), "should describe the violation")
+	}
+	filters, err := ParseFilterString("email==x")
+	assert.NoError(err, "should not return any parse error")
+	err = (&FilterPolicy{}).Check(filters)
+	assert.Error(err, "should return error for a policy without fields")
+}
+
+func TestListQueryPolicy(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	lqr := &ListQuery{
+		Collection: "stock",
+		Doc:        "doc",
+		Fmap:       fmap,
+		Filter:     "label=~dicty",
+		Limit:      10,
+		Policy:     testPolicy(),
+	}
+	_, err := lqr.Build()
+	var perr *PolicyError
+	assert.True(errors.As(err, &perr), "should reject filters violating the policy")
+	assert.Equal("label", perr.Field, "should report the offending field")
+	lqr.Filter = "summary=~dicty"
+	_, err = lqr.Build()
+	assert.NoError(err, "should accept filters conforming to the policy")
+}
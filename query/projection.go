@@ -0,0 +1,142 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// projectionNode is a key of the object literal generated for a
+// projection, it either holds the attribute reference or nested keys.
+type projectionNode struct {
+	ref      string
+	keys     []string
+	children map[string]*projectionNode
+}
+
+// GenAQLProjection generates the RETURN statement for the fields of a
+// field mask, i.e. the dotted paths of a protocol buffer FieldMask. Every
+// path must be a field of the field map, or the prefix of fields, so that
+// "name" selects both "name.first" and "name.last". Unknown paths are
+// rejected.
+//
+// If all the selected fields are top-level attributes with the same name,
+// the statement keeps the attributes of the document,
+//
+//	RETURN KEEP(doc, @projection)
+//
+// with the attributes in the projection bind parameter. Otherwise it
+// returns an object literal with the fields as keys,
+//
+//	RETURN { name: { first: doc.given_name }, email: doc.contact.email }
+//
+// The field map contains non-qualified database field names, that are
+// prefixed with the document variable.
+func GenAQLProjection(
+	fmap map[string]string,
+	mask []string,
+	doc string,
+) (string, map[string]interface{}, error) {
+	if len(mask) == 0 {
+		return "", nil, fmt.Errorf("empty field mask")
+	}
+	fields := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, path := range mask {
+		selected := maskFields(fmap, path)
+		if len(selected) == 0 {
+			return "", nil, fmt.Errorf("unknown field mask path %s", path)
+		}
+		for _, field := range selected {
+			if !seen[field] {
+				seen[field] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	if attrs, ok := keepAttributes(fmap, fields); ok {
+		return fmt.Sprintf("RETURN KEEP(%s, @projection)", doc),
+			map[string]interface{}{"projection": attrs}, nil
+	}
+	root := &projectionNode{children: make(map[string]*projectionNode)}
+	for _, field := range fields {
+		if err := root.add(
+			strings.Split(field, "."),
+			fmt.Sprintf("%s.%s", doc, fmap[field]),
+		); err != nil {
+			return "", nil, fmt.Errorf("invalid field %s: %w", field, err)
+		}
+	}
+
+	return fmt.Sprintf("RETURN %s", root.literal()), nil, nil
+}
+
+// maskFields returns the fields selected by a field mask path, sorted for
+// the paths that select more than one field.
+func maskFields(fmap map[string]string, path string) []string {
+	if _, ok := fmap[path]; ok {
+		return []string{path}
+	}
+	fields := make([]string, 0)
+	for field := range fmap {
+		if strings.HasPrefix(field, path+".") {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// keepAttributes returns the attributes for KEEP, if all the fields are
+// top-level attributes of the same name.
+func keepAttributes(fmap map[string]string, fields []string) ([]string, bool) {
+	for _, field := range fields {
+		if fmap[field] != field || strings.ContainsAny(field, ".[") {
+			return nil, false
+		}
+	}
+
+	return fields, true
+}
+
+func (pnd *projectionNode) add(keys []string, ref string) error {
+	key := keys[0]
+	if !fieldRegxp.MatchString(key) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	child, ok := pnd.children[key]
+	if !ok {
+		child = &projectionNode{children: make(map[string]*projectionNode)}
+		pnd.children[key] = child
+		pnd.keys = append(pnd.keys, key)
+	}
+	if len(keys) == 1 {
+		if len(child.children) > 0 {
+			return fmt.Errorf("key %s overlaps with its nested keys", key)
+		}
+		child.ref = ref
+
+		return nil
+	}
+	if len(child.ref) > 0 {
+		return fmt.Errorf("key %s overlaps with its nested keys", key)
+	}
+
+	return child.add(keys[1:], ref)
+}
+
+func (pnd *projectionNode) literal() string {
+	if len(pnd.ref) > 0 {
+		return pnd.ref
+	}
+	pairs := make([]string, 0, len(pnd.keys))
+	for _, key := range pnd.keys {
+		pairs = append(
+			pairs,
+			fmt.Sprintf("%s: %s", key, pnd.children[key].literal()),
+		)
+	}
+
+	return fmt.Sprintf("{ %s }", strings.Join(pairs, ", "))
+}
@@ -0,0 +1,101 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var projectionMap = map[string]string{
+	"id":              "_key",
+	"label":           "label",
+	"summary":         "summary",
+	"name.first":      "given_name",
+	"name.last":       "family_name",
+	"contact.email":   "contact.email",
+	"contact.phone":   "contact.phones[*].number",
+	"name":            "full_name",
+	"depositor.email": "depositor",
+}
+
+func TestGenAQLProjectionKeep(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, bindVars, err := GenAQLProjection(
+		projectionMap,
+		[]string{"label", "summary", "label"},
+		"doc",
+	)
+	assert.NoError(err, "should generate the projection")
+	assert.Equal("RETURN KEEP(doc, @projection)", stmt, "should keep the attributes")
+	assert.Equal(
+		map[string]interface{}{"projection": []string{"label", "summary"}},
+		bindVars,
+		"should bind the attributes once",
+	)
+}
+
+func TestGenAQLProjectionLiteral(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, bindVars, err := GenAQLProjection(
+		projectionMap,
+		[]string{"id", "contact", "depositor.email"},
+		"doc",
+	)
+	assert.NoError(err, "should generate the projection")
+	assert.Nil(bindVars, "should not need bind parameters")
+	assert.Equal(
+		"RETURN { id: doc._key, contact: { email: doc.contact.email, "+
+			"phone: doc.contact.phones[*].number }, "+
+			"depositor: { email: doc.depositor } }",
+		stmt,
+		"should rename and nest the keys",
+	)
+	stmt, _, err = GenAQLProjection(projectionMap, []string{"name.last", "label"}, "v")
+	assert.NoError(err, "should generate the projection")
+	assert.Equal(
+		"RETURN { name: { last: v.family_name }, label: v.label }",
+		stmt,
+		"should follow the order of the field mask",
+	)
+}
+
+func TestGenAQLProjectionErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for name, mask := range map[string][]string{
+		"empty mask":       {},
+		"unknown path":     {"label", "created_at"},
+		"partial segment":  {"contact.em"},
+		"overlapping keys": {"name", "name.first"},
+	} {
+		_, _, err := GenAQLProjection(projectionMap, mask, "doc")
+		assert.Errorf(err, "should return error for %s", name)
+	}
+	_, _, err := GenAQLProjection(
+		map[string]string{"first-name": "name.first"},
+		[]string{"first-name"},
+		"doc",
+	)
+	assert.Error(err, "should return error for invalid keys")
+}
+
+func TestListQueryNestedProjection(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, err := (&ListQuery{
+		Collection: "stock",
+		Doc:        "doc",
+		Fmap:       fmap,
+		Limit:      10,
+		Projection: []string{"sport", "email"},
+	}).Build()
+	assert.NoError(err, "should build the list query")
+	assert.Contains(
+		stmt.Query,
+		"RETURN { sport: doc.sports, email: doc.email }",
+		"should return the renamed fields",
+	)
+	assert.NotContains(stmt.BindVars, "projection", "should not bind the projection")
+}
@@ -3,9 +3,9 @@ package query
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
-	"github.com/dictyBase/arangomanager"
 	"github.com/dictyBase/arangomanager/collection"
 	"github.com/emirpasic/gods/lists/arraylist"
 	"github.com/go-playground/validator/v10"
@@ -14,9 +14,7 @@ import (
 
 const (
 	logicIdx         = 2
-	charSet          = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	filterStrLen     = 5
-	strSeedLen       = 10
 	arrQualMatchTmpl = `
 		LET %s = (
 			FOR x IN %s[*]
@@ -61,6 +59,7 @@ const (
 )
 
 var (
+	numberRegxp      = regexp.MustCompile(`^-?\d+(\.\d+)?([eE]-?\d+)?$`)
 	startPrefixRegxp = regexp.MustCompile(`\(`)
 	endPrefixRegxp   = regexp.MustCompile(`\)`)
 	validate         = validator.New()
@@ -122,7 +121,7 @@ func buildFilter() (*regexp.Regexp, error) {
 	bldr.WriteString(`(\w+)`)
 	bldr.WriteString(`(\=\=|\!\=|\=\=\=|\!\=\=|`)
 	bldr.WriteString(`\=\~|\!\~|>|<|>\=|`)
-	bldr.WriteString(`\=<|\$\=\=|\$\>|`)
+	bldr.WriteString(`\<\=|\$\=\=|\$\>|`)
 	bldr.WriteString(`\$\>\=|\$\<|\$\<\=|`)
 	bldr.WriteString(`\@\=\=|\@\!\=|`)
 	bldr.WriteString(`\@\!\~|\@\=\~)`)
@@ -189,6 +188,42 @@ func ParseFilterString(fstr string) ([]*Filter, error) {
 	return filters, nil
 }
 
+// parseWholeFilterString parses the filter string like ParseFilterString,
+// but returns an error if any part of it is not a filter or if it ends
+// with a logic operator, instead of dropping what could not be parsed.
+func parseWholeFilterString(fstr string) ([]*Filter, error) {
+	qre, err := buildFilter()
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	for _, loc := range qre.FindAllStringIndex(fstr, -1) {
+		if loc[0] != pos {
+			break
+		}
+		pos = loc[1]
+	}
+	if pos != len(fstr) {
+		return nil, fmt.Errorf(
+			"invalid filter string %q, could not parse %q", fstr, fstr[pos:],
+		)
+	}
+	filters, err := ParseFilterString(fstr)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("invalid filter string %q", fstr)
+	}
+	if len(filters[len(filters)-1].Logic) > 0 {
+		return nil, fmt.Errorf(
+			"invalid filter string %q, it ends with a logic operator", fstr,
+		)
+	}
+
+	return filters, nil
+}
+
 // validateFilterFields checks if all filter fields are present in the field map.
 // Returns an error if any field is missing.
 func validateFilterFields(fmap map[string]string, filters []*Filter) error {
@@ -219,15 +254,15 @@ func handleQualifiedArrayFilter(
 	stmts map[string]*arraylist.List,
 	flt *Filter,
 	fmap map[string]string,
+	varName string,
 ) {
-	randStr := arangomanager.FixedLenRandomString(strSeedLen)
 	switch getArrayOpertaor(flt.Operator) {
 	case "=~":
 		stmts["let"].Insert(
 			0,
 			fmt.Sprintf(
 				arrQualMatchTmpl,
-				randStr,
+				varName,
 				fmap[flt.Field],
 				flt.Value,
 			),
@@ -237,7 +272,7 @@ func handleQualifiedArrayFilter(
 			0,
 			fmt.Sprintf(
 				arrQualEqualTmpl,
-				randStr,
+				varName,
 				flt.Value,
 				fmap[flt.Field],
 			),
@@ -247,12 +282,12 @@ func handleQualifiedArrayFilter(
 			0,
 			fmt.Sprintf(
 				arrQualNotEqualTmpl,
-				randStr,
+				varName,
 				flt.Value,
 				fmap[flt.Field],
 			))
 	}
-	stmts["nonlet"].Add(fmt.Sprintf("LENGTH(%s) > 0", randStr))
+	stmts["nonlet"].Add(fmt.Sprintf("LENGTH(%s) > 0", varName))
 }
 
 // GenQualifiedAQLFilterStatement generates an AQL (ArangoDB Query Language)
@@ -266,14 +301,21 @@ func handleQualifiedArrayFilter(
 //
 // This function handles standard operators, date comparisons, and array operations,
 // generating the appropriate LET statements and filter conditions in AQL syntax.
+// Paths that expand arrays of objects, like "doc.phones[*].type", generate
+// inline expressions that match if any of the array elements does.
 // It also manages logical operators (AND/OR) between filter expressions and
 // ensures proper parenthetical grouping.
+// The LET variables are named deterministically, so identical filters always
+// generate identical statements, the naming could be changed with the
+// WithVarNamer option.
 // Parameters:
 //   - fmap: A map of field names to their fully qualified database field paths
 //   - filters: A slice of Filter structures containing the filter criteria
+//   - opts: Optional FilterOption values
 func GenQualifiedAQLFilterStatement(
 	fmap map[string]string,
 	filters []*Filter,
+	opts ...FilterOption,
 ) (string, error) {
 	if err := validate.Struct(&AQLFilterParams{
 		Fmap:    fmap,
@@ -291,11 +333,20 @@ func GenQualifiedAQLFilterStatement(
 		"nonlet": arraylist.New(),
 	}
 
+	fopts := newFilterOptions(opts)
 	// Process each filter
-	for _, flt := range filters {
+	for idx, flt := range filters {
 		switch {
+		case hasInlineArrayFilter(fmap[flt.Field], flt):
+			expr, err := genInlineArrayExpr(fmap[flt.Field], flt)
+			if err != nil {
+				return "", err
+			}
+			stmts["nonlet"].Add(expr)
 		case hasArrayOperator(flt.Operator):
-			handleQualifiedArrayFilter(stmts, flt, fmap)
+			handleQualifiedArrayFilter(
+				stmts, flt, fmap, fopts.namer(idx, flt),
+			)
 		case hasDateOperator(flt.Operator):
 			if err := dateValidator(flt.Value); err != nil {
 				return "", err
@@ -327,7 +378,7 @@ func GenQualifiedAQLFilterStatement(
 func handleArrayOpertaor(
 	prms *StatementParameters,
 	flt *Filter,
-	randStr string,
+	varName string,
 ) string {
 	inner := prms.Doc
 	var stmt string
@@ -335,7 +386,7 @@ func handleArrayOpertaor(
 	case "=~":
 		stmt = fmt.Sprintf(
 			arrMatchTmpl,
-			randStr,
+			varName,
 			inner,
 			prms.Fmap[flt.Field],
 			flt.Value,
@@ -343,7 +394,7 @@ func handleArrayOpertaor(
 	case "==":
 		stmt = fmt.Sprintf(
 			arrEqualTmpl,
-			randStr,
+			varName,
 			flt.Value,
 			inner,
 			prms.Fmap[flt.Field],
@@ -351,7 +402,7 @@ func handleArrayOpertaor(
 	case "!=":
 		stmt = fmt.Sprintf(
 			arrNotEqualTmpl,
-			randStr,
+			varName,
 			flt.Value,
 			inner,
 			prms.Fmap[flt.Field],
@@ -369,14 +420,20 @@ func handleArrayOpertaor(
 //
 // This function handles standard operators, date comparisons, and array operations,
 // and supports both document-based queries and graph traversal queries through the
-// Document and Vertex parameters.
+// Document and Vertex parameters. Like GenQualifiedAQLFilterStatement, it
+// supports paths that expand arrays of objects, like "phones[*].type".
 //
 // Parameters:
 //   - prms: A StatementParameters struct containing the filter map, filters,
 //     document variable name, and optional vertex variable name
+//   - opts: Optional FilterOption values, i.e. WithVarNamer for naming the
+//     LET variables
 //
 // Returns the generated AQL filter statement as a string and any error encountered.
-func GenAQLFilterStatement(prms *StatementParameters) (string, error) {
+func GenAQLFilterStatement(
+	prms *StatementParameters,
+	opts ...FilterOption,
+) (string, error) {
 	if err := validate.Struct(prms); err != nil {
 		return "", fmt.Errorf(
 			"validation error in StatementParameters: %w",
@@ -389,12 +446,22 @@ func GenAQLFilterStatement(prms *StatementParameters) (string, error) {
 	if len(prms.Vert) > 0 {
 		inner = prms.Vert
 	}
-	for _, flt := range prms.Filters {
+	fopts := newFilterOptions(opts)
+	for idx, flt := range prms.Filters {
 		switch {
+		case hasInlineArrayFilter(prms.Fmap[flt.Field], flt):
+			expr, err := genInlineArrayExpr(
+				fmt.Sprintf("%s.%s", inner, prms.Fmap[flt.Field]),
+				flt,
+			)
+			if err != nil {
+				return "", err
+			}
+			stmts.Add(expr)
 		case hasArrayOperator(flt.Operator):
-			randStr := arangomanager.FixedLenRandomString(strSeedLen)
-			stmts.Insert(0, handleArrayOpertaor(prms, flt, randStr))
-			stmts.Add(fmt.Sprintf("LENGTH(%s) > 0", randStr))
+			varName := fopts.namer(idx, flt)
+			stmts.Insert(0, handleArrayOpertaor(prms, flt, varName))
+			stmts.Add(fmt.Sprintf("LENGTH(%s) > 0", varName))
 		case hasDateOperator(flt.Operator):
 			if err := dateValidator(flt.Value); err != nil {
 				return "", err
@@ -531,6 +598,22 @@ func addQuoteToStrings(ops, value string) string {
 	return value
 }
 
+// numberValidator checks the value of the comparison operators >, >=, <
+// and <=, which is written into the statement without quotes and has to
+// be a number.
+func numberValidator(flt *Filter) error {
+	if !slices.Contains([]string{">", ">=", "<", "<="}, flt.Operator) {
+		return nil
+	}
+	if !numberRegxp.MatchString(strings.TrimSpace(flt.Value)) {
+		return fmt.Errorf(
+			"value %q of operator %s is not a number", flt.Value, flt.Operator,
+		)
+	}
+
+	return nil
+}
+
 func dateValidator(str string) error {
 	// get all regex matches for date
 	dre, err := buildDate()
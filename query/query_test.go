@@ -122,6 +122,13 @@ func TestParseFilterString(t *testing.T) {
 	assert.Equal(fls2[0].Logic, ";", "should have parsed colon logic")
 	assert.Empty(fls2[1].Logic, "should have empty logic value")
 
+	fls3, err := ParseFilterString("age<=21;age>=18")
+	assert.NoError(err, "should not return any parse error")
+	assert.Len(fls3, 2, "should parse the less or equal operator")
+	assert.Equal("<=", fls3[0].Operator, "should match the less or equal operator")
+	assert.Equal("21", fls3[0].Value, "should not include the = in the value")
+	assert.Equal(">=", fls3[1].Operator, "should match the greater or equal operator")
+
 	b, err := ParseFilterString("xyz")
 	assert.NoError(err, "should not return any parse error")
 	assert.Len(b, 0, "should have empty slice since regex doesn't match string")
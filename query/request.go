@@ -0,0 +1,239 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the list request parameters.
+const (
+	FilterParam  = "filter"
+	OrderByParam = "order_by"
+	LimitParam   = "limit"
+	CursorParam  = "cursor"
+)
+
+// RequestError is returned for invalid parameters of a list request, it
+// is caused by the client and usually maps to a 400 (Bad Request)
+// response.
+type RequestError struct {
+	// Name of the offending parameter
+	Param string
+	// The validation error
+	Err error
+}
+
+func (rerr *RequestError) Error() string {
+	return fmt.Sprintf("invalid %s parameter: %s", rerr.Param, rerr.Err)
+}
+
+func (rerr *RequestError) Unwrap() error {
+	return rerr.Err
+}
+
+// ListSchema describes the list queries accepted for a collection.
+type ListSchema struct {
+	// Name of the collection to loop over
+	Collection string `validate:"required"`
+	// The variable used for looping inside the collection
+	Doc string `validate:"required"`
+	// Map of filter and sort fields to database fields
+	Fmap map[string]string `validate:"required,min=1"`
+	// Policy the filters have to conform to, optional
+	Policy *FilterPolicy
+	// Page size used when the request has no limit
+	DefaultLimit int `validate:"gte=1"`
+	// Largest page size a request could ask for
+	MaxLimit int `validate:"gtefield=DefaultLimit"`
+	// Sort string used when the request has no order
+	DefaultSort string
+	// Fields allowed in sort strings, nil allows every field of Fmap
+	Sortable []string
+	// Field mask of the fields to return, the whole document is returned
+	// if empty
+	Projection []string
+	// Secret for signing page tokens, setting it switches to keyset
+	// pagination, the cursor is then a page token instead of an offset
+	TokenSecret []byte
+}
+
+// ListRequest is a list request as a plain struct, i.e. the fields of a
+// protocol buffer list message.
+type ListRequest struct {
+	// Filter string as accepted by ParseFilterString
+	Filter string
+	// Sort string as accepted by ParseSortString
+	OrderBy string
+	// Page size, the default of the schema is used if zero
+	Limit int64
+	// Offset of the page, or the page token for keyset pagination
+	Cursor string
+}
+
+// FromURLValues builds the list query from the filter, order_by, limit
+// and cursor query parameters. Every parameter is validated and any
+// invalid one is reported as a *RequestError, other parameters of the
+// request are ignored.
+func FromURLValues(vals url.Values, schema *ListSchema) (*Statement, error) {
+	req := &ListRequest{}
+	params := []struct {
+		name  string
+		value *string
+	}{
+		{FilterParam, &req.Filter},
+		{OrderByParam, &req.OrderBy},
+		{CursorParam, &req.Cursor},
+	}
+	for _, prm := range params {
+		str, err := singleValue(vals, prm.name)
+		if err != nil {
+			return nil, err
+		}
+		*prm.value = str
+	}
+	limit, err := singleValue(vals, LimitParam)
+	if err != nil {
+		return nil, err
+	}
+	if len(limit) > 0 {
+		num, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return nil, &RequestError{
+				Param: LimitParam,
+				Err:   fmt.Errorf("%q is not a number", limit),
+			}
+		}
+		req.Limit = num
+	}
+
+	return FromListRequest(req, schema)
+}
+
+// FromListRequest builds the list query from a ListRequest. Every field is
+// validated and any invalid one is reported as a *RequestError, while
+// errors of the schema are returned as they are.
+func FromListRequest(req *ListRequest, schema *ListSchema) (*Statement, error) {
+	if err := validate.Struct(schema); err != nil {
+		return nil, fmt.Errorf("validation error in ListSchema: %w", err)
+	}
+	lqr := &ListQuery{
+		Collection:  schema.Collection,
+		Doc:         schema.Doc,
+		Fmap:        schema.Fmap,
+		Filter:      strings.TrimSpace(req.Filter),
+		Policy:      schema.Policy,
+		Sort:        strings.TrimSpace(req.OrderBy),
+		Limit:       schema.DefaultLimit,
+		Projection:  schema.Projection,
+		TokenSecret: schema.TokenSecret,
+	}
+	if err := checkFilterParam(lqr); err != nil {
+		return nil, &RequestError{Param: FilterParam, Err: err}
+	}
+	if len(lqr.Sort) == 0 {
+		lqr.Sort = schema.DefaultSort
+	}
+	sorts, err := checkOrderByParam(lqr)
+	if err != nil {
+		return nil, &RequestError{Param: OrderByParam, Err: err}
+	}
+	if err := checkSortable(schema.Sortable, sorts); err != nil {
+		return nil, &RequestError{Param: OrderByParam, Err: err}
+	}
+	if req.Limit != 0 {
+		if req.Limit < 1 || req.Limit > int64(schema.MaxLimit) {
+			return nil, &RequestError{
+				Param: LimitParam,
+				Err: fmt.Errorf(
+					"%d is not between 1 and %d",
+					req.Limit, schema.MaxLimit,
+				),
+			}
+		}
+		lqr.Limit = int(req.Limit)
+	}
+	if err := checkCursorParam(lqr, sorts, strings.TrimSpace(req.Cursor)); err != nil {
+		return nil, &RequestError{Param: CursorParam, Err: err}
+	}
+
+	return lqr.Build()
+}
+
+func singleValue(vals url.Values, name string) (string, error) {
+	values := vals[name]
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		return values[0], nil
+	default:
+		return "", &RequestError{
+			Param: name,
+			Err:   fmt.Errorf("expected a single value, got %d", len(values)),
+		}
+	}
+}
+
+// checkFilterParam generates the filter statement of the query, so that
+// the values of the filters, such as dates, are checked along with the
+// fields and the policy.
+func checkFilterParam(lqr *ListQuery) error {
+	_, err := lqr.filterStatement()
+
+	return err
+}
+
+func checkOrderByParam(lqr *ListQuery) ([]*Sort, error) {
+	sorts, err := ParseSortString(lqr.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	return sorts, validateSortFields(lqr.Fmap, sorts)
+}
+
+func checkSortable(sortable []string, sorts []*Sort) error {
+	if sortable == nil {
+		return nil
+	}
+	for _, srt := range sorts {
+		if !slices.Contains(sortable, srt.Field) {
+			return fmt.Errorf("field %s is not sortable", srt.Field)
+		}
+	}
+
+	return nil
+}
+
+// checkCursorParam sets the cursor of the query, it is an offset for
+// offset pagination and a page token for keyset pagination.
+func checkCursorParam(lqr *ListQuery, sorts []*Sort, cursor string) error {
+	if len(cursor) == 0 {
+		return nil
+	}
+	if len(lqr.TokenSecret) == 0 {
+		offset, err := strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			return fmt.Errorf("%q is not a non-negative number", cursor)
+		}
+		lqr.Cursor = offset
+
+		return nil
+	}
+	kst := &Keyset{
+		Fmap:   lqr.Fmap,
+		Sorts:  sorts,
+		Doc:    lqr.Doc,
+		Secret: lqr.TokenSecret,
+		Filter: lqr.Filter,
+	}
+	if _, err := kst.DecodeToken(cursor); err != nil {
+		return err
+	}
+	lqr.PageToken = cursor
+
+	return nil
+}
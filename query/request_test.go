@@ -0,0 +1,154 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSchema() *ListSchema {
+	return &ListSchema{
+		Collection:   "stock",
+		Doc:          "doc",
+		Fmap:         fmap,
+		DefaultLimit: 10,
+		MaxLimit:     50,
+		DefaultSort:  "-created_at",
+	}
+}
+
+func TestFromURLValues(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	stmt, err := FromURLValues(url.Values{
+		"filter":   {"email==mahomes@gmail.com"},
+		"order_by": {"label,-created_at"},
+		"limit":    {"20"},
+		"cursor":   {"40"},
+		"other":    {"ignored", "values"},
+	}, testSchema())
+	assert.NoError(err, "should build the list query")
+	assert.Equal(
+		`FOR doc IN @@collection
+	FILTER doc.email == 'mahomes@gmail.com'
+	SORT doc.label ASC, doc.created_at DESC
+	LIMIT @offset, @limit
+	RETURN doc`,
+		stmt.Query,
+		"should match the list query",
+	)
+	assert.Equal(
+		map[string]interface{}{"@collection": "stock", "offset": 40, "limit": 20},
+		stmt.BindVars,
+		"should match the bind parameters",
+	)
+	stmt, err = FromURLValues(url.Values{}, testSchema())
+	assert.NoError(err, "should build the list query without parameters")
+	assert.Contains(stmt.Query, "SORT doc.created_at DESC", "should use the default sort")
+	assert.Equal(10, stmt.BindVars["limit"], "should use the default limit")
+}
+
+func TestFromURLValuesErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	schema := testSchema()
+	schema.Policy = &FilterPolicy{
+		Fields: map[string]*FieldPolicy{"email": {Operators: []string{"=="}}},
+	}
+	for param, vals := range map[string]url.Values{
+		"filter":   {"filter": {"email=~gmail"}},
+		"order_by": {"order_by": {"-age"}},
+		"limit":    {"limit": {"ten"}},
+		"cursor":   {"cursor": {"-10"}},
+	} {
+		_, err := FromURLValues(vals, schema)
+		var rerr *RequestError
+		assert.Truef(errors.As(err, &rerr), "should return a request error for %s", param)
+		assert.Equal(param, rerr.Param, "should report the offending parameter")
+	}
+	_, err := FromURLValues(url.Values{"limit": {"10", "20"}}, schema)
+	assert.EqualError(
+		err,
+		"invalid limit parameter: expected a single value, got 2",
+		"should reject repeated parameters",
+	)
+	_, err = FromURLValues(url.Values{"limit": {"100"}}, schema)
+	assert.EqualError(
+		err,
+		"invalid limit parameter: 100 is not between 1 and 50",
+		"should reject limits above the maximum",
+	)
+	_, err = FromURLValues(url.Values{"filter": {"email=~gmail"}}, schema)
+	var perr *PolicyError
+	assert.True(errors.As(err, &perr), "should wrap the policy error")
+	_, err = FromURLValues(url.Values{"filter": {"created_at$>2019-13"}}, testSchema())
+	var derr *RequestError
+	assert.True(errors.As(err, &derr), "should return a request error for an invalid date")
+	assert.Equal(FilterParam, derr.Param, "should report the filter parameter for an invalid date")
+	schema.Sortable = []string{"created_at"}
+	_, err = FromURLValues(url.Values{"order_by": {"label"}}, schema)
+	assert.EqualError(
+		err,
+		"invalid order_by parameter: field label is not sortable",
+		"should reject sort fields that are not sortable",
+	)
+	_, err = FromURLValues(url.Values{"order_by": {"-created_at"}}, schema)
+	assert.NoError(err, "should accept sortable fields")
+	_, err = FromURLValues(url.Values{}, &ListSchema{Collection: "stock"})
+	assert.Error(err, "should return error for invalid schema")
+	var rerr *RequestError
+	assert.False(errors.As(err, &rerr), "should not blame the request for the schema")
+}
+
+func TestFromListRequestMalformedFilter(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for _, fstr := range []string{
+		"email==john;",
+		"email==john,",
+		"email==john;garbage!!",
+		"email==jo'hn",
+		"created_at>abc",
+		"created_at$>2019-13",
+	} {
+		_, err := FromListRequest(&ListRequest{Filter: fstr}, testSchema())
+		var rerr *RequestError
+		assert.Truef(errors.As(err, &rerr), "should return a request error for %s", fstr)
+		assert.Equalf(FilterParam, rerr.Param, "should report the filter parameter for %s", fstr)
+	}
+	_, err := FromListRequest(&ListRequest{Filter: "created_at>20;email==john"}, testSchema())
+	assert.NoError(err, "should accept a numeric comparison")
+}
+
+func TestFromListRequestKeyset(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	schema := &ListSchema{
+		Collection:   "users",
+		Doc:          "doc",
+		Fmap:         testKeyset().Fmap,
+		DefaultLimit: 10,
+		MaxLimit:     10,
+		DefaultSort:  "-created_at,first_name",
+		TokenSecret:  tokenSecret,
+	}
+	token, err := testKeyset().EncodeToken(map[string]interface{}{
+		"_key":       "1234",
+		"created_at": "2020-01-01",
+		"name":       map[string]interface{}{"first": "Lue"},
+	})
+	assert.NoError(err, "should not return any error from encoding token")
+	stmt, err := FromListRequest(&ListRequest{Cursor: token}, schema)
+	assert.NoError(err, "should build the keyset paginated query")
+	assert.Equal("2020-01-01", stmt.BindVars["keyset0"], "should continue after the token")
+	_, err = FromListRequest(
+		&ListRequest{Cursor: token, OrderBy: "first_name"},
+		schema,
+	)
+	assert.ErrorIs(err, ErrInvalidPageToken, "should reject token of another sort order")
+	var rerr *RequestError
+	assert.True(errors.As(err, &rerr), "should return a request error")
+	assert.Equal(CursorParam, rerr.Param, "should report the cursor parameter")
+}
@@ -0,0 +1,282 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultNgramThreshold = 0.7
+	lowerBound            = "lower"
+	upperBound            = "upper"
+)
+
+// SearchField describes how a field is indexed in an ArangoSearch view.
+type SearchField struct {
+	// Name of the analyzer the field is indexed with, i.e. "text_en".
+	// Fields without an analyzer are matched with the identity analyzer.
+	Analyzer string
+	// Name of an n-gram analyzer the field is also indexed with, enables
+	// fuzzy matching of the field with NGRAM_MATCH.
+	Ngram string
+	// Similarity threshold for NGRAM_MATCH between 0 and 1, defaults to 0.7
+	Threshold float64 `validate:"gte=0,lte=1"`
+}
+
+// SearchParameters is a container for elements needed in the AQL SEARCH
+// statement.
+type SearchParameters struct {
+	// Map of filters to database fields
+	Fmap map[string]string `validate:"required,min=1"`
+	// Slice of Filter structs, contains all necessary items for AQL statement
+	Filters []*Filter `validate:"required,min=1,dive"`
+	// The variable used for looping inside a view (i.e. the "d" in "FOR d IN stock_view")
+	Doc string `validate:"required"`
+	// Map of filter fields to their indexing in the view
+	Fields map[string]*SearchField `validate:"omitempty,dive"`
+	// Sort the documents by their BM25 relevance score, most relevant first
+	SortByScore bool
+}
+
+// GenAQLSearchStatement generates an AQL SEARCH statement for an
+// ArangoSearch view from the same filters as GenAQLFilterStatement. The
+// field map contains non-qualified database field names, that are
+// prefixed with the document variable. The filters are translated as
+//
+//	==, @==        ANALYZER(PHRASE(doc.field, 'value'), 'analyzer')
+//	=~, @=~        ANALYZER(STARTS_WITH(doc.field, 'value'), 'analyzer'),
+//	               or NGRAM_MATCH if the field has an n-gram analyzer
+//	!=, !~, @!=... NOT of the above
+//	>, <, $>, ...  comparisons, a lower and an upper bound on the same
+//	               field are combined into IN_RANGE
+//
+// Fields without an analyzer are compared as they are, for example
+// doc.field == 'value'. As the view indexes the elements of arrays, paths
+// that expand arrays are searched without the expansion, i.e.
+// "phones[*].type" becomes doc.phones.type.
+// The filters are combined the same way as in the FILTER statement, when
+// SortByScore is set, a "SORT BM25(doc) DESC" statement is appended.
+func GenAQLSearchStatement(prms *SearchParameters) (string, error) {
+	if err := validate.Struct(prms); err != nil {
+		return "", fmt.Errorf(
+			"validation error in SearchParameters: %w",
+			err,
+		)
+	}
+	if err := validateFilterFields(prms.Fmap, prms.Filters); err != nil {
+		return "", err
+	}
+	groups := toConjunction(prms.Filters)
+	ranges := rangeFilters(groups)
+	clauses := make([]string, 0, len(groups))
+	for _, grp := range groups {
+		exprs := make([]string, 0, len(grp))
+		for _, flt := range grp {
+			expr, err := searchExpression(prms, flt, ranges)
+			if err != nil {
+				return "", err
+			}
+			if len(expr) > 0 {
+				exprs = append(exprs, expr)
+			}
+		}
+		switch len(exprs) {
+		case 0:
+			continue
+		case 1:
+			clauses = append(clauses, exprs[0])
+		default:
+			clauses = append(
+				clauses,
+				fmt.Sprintf("(%s)", strings.Join(exprs, " OR ")),
+			)
+		}
+	}
+	stmt := fmt.Sprintf("SEARCH %s", strings.Join(clauses, "\n AND "))
+	if prms.SortByScore {
+		stmt = fmt.Sprintf("%s\nSORT BM25(%s) DESC", stmt, prms.Doc)
+	}
+
+	return stmt, nil
+}
+
+// searchRange is the pair of bounds on a field that are combined into an
+// IN_RANGE expression.
+type searchRange struct {
+	lower *Filter
+	upper *Filter
+}
+
+// rangeFilters finds the fields with both a lower and an upper bound in
+// the conjunction. Only the filters that are not part of a disjunction
+// could be combined.
+func rangeFilters(groups [][]*Filter) map[*Filter]*searchRange {
+	bounds := make(map[string]*searchRange)
+	for _, grp := range groups {
+		if len(grp) != 1 {
+			continue
+		}
+		flt := grp[0]
+		if _, ok := bounds[flt.Field]; !ok {
+			bounds[flt.Field] = &searchRange{}
+		}
+		rng := bounds[flt.Field]
+		switch boundKind(flt) {
+		case lowerBound:
+			if rng.lower == nil {
+				rng.lower = flt
+			}
+		case upperBound:
+			if rng.upper == nil {
+				rng.upper = flt
+			}
+		}
+	}
+	ranges := make(map[*Filter]*searchRange)
+	for _, rng := range bounds {
+		if rng.lower != nil && rng.upper != nil {
+			ranges[rng.lower] = rng
+			ranges[rng.upper] = rng
+		}
+	}
+
+	return ranges
+}
+
+func boundKind(flt *Filter) string {
+	if hasArrayOperator(flt.Operator) {
+		return ""
+	}
+	switch getOperator(flt.Operator) {
+	case ">", ">=":
+		return lowerBound
+	case "<", "<=":
+		return upperBound
+	default:
+		return ""
+	}
+}
+
+// searchExpression generates the SEARCH expression of a filter, the
+// filters of a range are generated once, at the position of the lower
+// bound.
+func searchExpression(
+	prms *SearchParameters,
+	flt *Filter,
+	ranges map[*Filter]*searchRange,
+) (string, error) {
+	ref := fmt.Sprintf(
+		"%s.%s",
+		prms.Doc,
+		strings.ReplaceAll(prms.Fmap[flt.Field], arrayExpansion, ""),
+	)
+	field := prms.Fields[flt.Field]
+	if field == nil {
+		field = &SearchField{}
+	}
+	if rng, ok := ranges[flt]; ok {
+		if rng.lower != flt {
+			return "", nil
+		}
+
+		return genRangeExpression(ref, rng)
+	}
+	if !hasOperator(flt.Operator) {
+		return "", fmt.Errorf("unknown opertaor for parsing %s", flt.Operator)
+	}
+	opr := getOperator(flt.Operator)
+	switch opr {
+	case "==", "!=":
+		if hasDateOperator(flt.Operator) {
+			return genDateComparison(ref, opr, flt.Value)
+		}
+
+		return negateSearch(opr == "!=", genPhrase(ref, field, flt.Value)), nil
+	case "=~", "!~":
+		return negateSearch(opr == "!~", genPrefix(ref, field, flt.Value)), nil
+	default:
+		if hasDateOperator(flt.Operator) {
+			return genDateComparison(ref, opr, flt.Value)
+		}
+
+		return fmt.Sprintf("%s %s %s", ref, opr, flt.Value), nil
+	}
+}
+
+func genPhrase(ref string, field *SearchField, value string) string {
+	if len(field.Analyzer) == 0 {
+		return fmt.Sprintf("%s == '%s'", ref, value)
+	}
+
+	return fmt.Sprintf(
+		"ANALYZER(PHRASE(%s, '%s'), '%s')",
+		ref, value, field.Analyzer,
+	)
+}
+
+func genPrefix(ref string, field *SearchField, value string) string {
+	if len(field.Ngram) > 0 {
+		threshold := field.Threshold
+		if threshold == 0 {
+			threshold = defaultNgramThreshold
+		}
+
+		return fmt.Sprintf(
+			"NGRAM_MATCH(%s, '%s', %g, '%s')",
+			ref, value, threshold, field.Ngram,
+		)
+	}
+	if len(field.Analyzer) == 0 {
+		return fmt.Sprintf("STARTS_WITH(%s, '%s')", ref, value)
+	}
+
+	return fmt.Sprintf(
+		"ANALYZER(STARTS_WITH(%s, '%s'), '%s')",
+		ref, value, field.Analyzer,
+	)
+}
+
+func negateSearch(negate bool, expr string) string {
+	if !negate {
+		return expr
+	}
+
+	return fmt.Sprintf("NOT (%s)", expr)
+}
+
+func genDateComparison(ref, opr, value string) (string, error) {
+	if err := dateValidator(value); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s DATE_ISO8601('%s')", ref, opr, value), nil
+}
+
+func genRangeExpression(ref string, rng *searchRange) (string, error) {
+	low, err := rangeValue(rng.lower)
+	if err != nil {
+		return "", err
+	}
+	high, err := rangeValue(rng.upper)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"IN_RANGE(%s, %s, %s, %t, %t)",
+		ref, low, high,
+		getOperator(rng.lower.Operator) == ">=",
+		getOperator(rng.upper.Operator) == "<=",
+	), nil
+}
+
+func rangeValue(flt *Filter) (string, error) {
+	if !hasDateOperator(flt.Operator) {
+		return flt.Value, nil
+	}
+	if err := dateValidator(flt.Value); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("DATE_ISO8601('%s')", flt.Value), nil
+}
@@ -0,0 +1,119 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var searchFields = map[string]*SearchField{
+	"summary":  {Analyzer: "text_en"},
+	"ontology": {Analyzer: "text_en", Ngram: "trigram"},
+	"tag":      {Ngram: "trigram", Threshold: 0.5},
+}
+
+func genSearch(
+	assert *require.Assertions,
+	fstr string,
+	sortByScore bool,
+) string {
+	filters, err := ParseFilterString(fstr)
+	assert.NoError(err, "should not return any parse error")
+	stmt, err := GenAQLSearchStatement(&SearchParameters{
+		Fmap:        fmap,
+		Filters:     filters,
+		Doc:         "doc",
+		Fields:      searchFields,
+		SortByScore: sortByScore,
+	})
+	assert.NoError(err, "should not return error when generating AQL search statement")
+
+	return stmt
+}
+
+func TestGenAQLSearchStatement(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	assert.Equal(
+		"SEARCH ANALYZER(PHRASE(doc.summary, 'cold shock'), 'text_en')",
+		genSearch(assert, "summary==cold shock", false),
+		"should search analyzed fields with phrases",
+	)
+	assert.Equal(
+		"SEARCH doc.email == 'mahomes@gmail.com'\n AND NOT (doc.label == 'dicty')",
+		genSearch(assert, "email==mahomes@gmail.com;label!=dicty", false),
+		"should compare fields without analyzer",
+	)
+	assert.Equal(
+		"SEARCH (ANALYZER(STARTS_WITH(doc.summary, 'shock'), 'text_en') OR NGRAM_MATCH(doc.ontology, 'dicty', 0.7, 'trigram'))",
+		genSearch(assert, "summary=~shock,ontology=~dicty", false),
+		"should match prefixes or ngrams in a disjunction",
+	)
+	assert.Equal(
+		"SEARCH NOT (NGRAM_MATCH(doc.tag, 'remi', 0.5, 'trigram'))\n AND doc.sports == 'basketball'",
+		genSearch(assert, "tag@!~remi;sport@==basketball", false),
+		"should negate matches and search array elements",
+	)
+	assert.Equal(
+		"SEARCH IN_RANGE(doc.created_at, DATE_ISO8601('2018'), DATE_ISO8601('2020'), true, false)\n AND doc.label == 'dicty'",
+		genSearch(assert, "created_at$>=2018;label==dicty;created_at$<2020", false),
+		"should combine lower and upper bounds into a range",
+	)
+	assert.Equal(
+		"SEARCH (doc.created_at > DATE_ISO8601('2018') OR doc.label == 'dicty')\n AND doc.created_at < DATE_ISO8601('2020')",
+		genSearch(assert, "created_at$>2018,label==dicty;created_at$<2020", false),
+		"should not combine bounds that are part of a disjunction",
+	)
+	assert.Equal(
+		"SEARCH ANALYZER(PHRASE(doc.summary, 'shock'), 'text_en')\nSORT BM25(doc) DESC",
+		genSearch(assert, "summary==shock", true),
+		"should sort by relevance",
+	)
+}
+
+func TestGenAQLSearchStatementArrayPath(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	filters, err := ParseFilterString("phone==mobile")
+	assert.NoError(err, "should not return any parse error")
+	stmt, err := GenAQLSearchStatement(&SearchParameters{
+		Fmap:    map[string]string{"phone": "contact.phones[*].type"},
+		Filters: filters,
+		Doc:     "doc",
+	})
+	assert.NoError(err, "should not return error when generating AQL search statement")
+	assert.Equal(
+		"SEARCH doc.contact.phones.type == 'mobile'",
+		stmt,
+		"should search array paths without expansion",
+	)
+}
+
+func TestGenAQLSearchStatementErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	_, err := GenAQLSearchStatement(&SearchParameters{
+		Fmap:    fmap,
+		Filters: []*Filter{{Field: "name", Operator: "==", Value: "x"}},
+		Doc:     "doc",
+	})
+	assert.Error(err, "should return error for unmapped fields")
+	_, err = GenAQLSearchStatement(&SearchParameters{
+		Fmap:    fmap,
+		Filters: []*Filter{{Field: "created_at", Operator: "$>", Value: "2019-13"}},
+		Doc:     "doc",
+	})
+	assert.Error(err, "should return error for invalid dates")
+	_, err = GenAQLSearchStatement(&SearchParameters{
+		Fmap: fmap,
+		Doc:  "doc",
+	})
+	assert.Error(err, "should return error without filters")
+	_, err = GenAQLSearchStatement(&SearchParameters{
+		Fmap:    fmap,
+		Filters: []*Filter{{Field: "tag", Operator: "=~", Value: "x"}},
+		Doc:     "doc",
+		Fields:  map[string]*SearchField{"tag": {Ngram: "trigram", Threshold: 2}},
+	})
+	assert.Error(err, "should return error for invalid threshold")
+}
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dictyBase/arangomanager/collection"
+)
+
+var sortFieldRegxp = regexp.MustCompile(`^(\-|\+)?(\w+)$`)
+
+// Sort is a container for sort parameters.
+type Sort struct {
+	// Field of the object on which the sort will be applied
+	Field string `validate:"required"`
+	// Sort in descending order, ascending otherwise
+	Descending bool
+}
+
+// AQLSortParams defines validation for GenQualifiedAQLSortStatement parameters
+type AQLSortParams struct {
+	// Map of fields to database paths
+	Fmap map[string]string `validate:"required,min=1"`
+	// Slice of Sort structs
+	Sorts []*Sort `validate:"required,min=1,dive"`
+}
+
+// SortParameters is a container for elements needed in the AQL SORT statement.
+type SortParameters struct {
+	// Map of sort fields to database fields
+	Fmap map[string]string `validate:"required"`
+	// Slice of Sort structs, contains all necessary items for AQL statement
+	Sorts []*Sort `validate:"required,min=1,dive"`
+	// The variable used for looping inside a collection (i.e. the "s" in "FOR s IN stock")
+	Doc string `validate:"required"`
+	// The variable used for looping inside a graph (i.e. the "v" in "FOR v IN 1..1 OUTBOUND s GRAPH 'xyz'")
+	Vert string
+}
+
+// ParseSortString parses a sort string into a slice of Sort structures.
+// The sort string is a comma separated list of fields, each of them
+// optionally prefixed with "-" for descending or "+" for ascending order,
+// for example "-created_at,name". Fields without a prefix are sorted
+// in ascending order.
+//
+// Returns an error if any of the fields is malformed.
+func ParseSortString(sstr string) ([]*Sort, error) {
+	sorts := make([]*Sort, 0)
+	if len(strings.TrimSpace(sstr)) == 0 {
+		return sorts, nil
+	}
+	for _, part := range strings.Split(sstr, ",") {
+		mtc := sortFieldRegxp.FindStringSubmatch(strings.TrimSpace(part))
+		if mtc == nil {
+			return sorts, fmt.Errorf("invalid sort field %q", part)
+		}
+		sorts = append(sorts, &Sort{Field: mtc[2], Descending: mtc[1] == "-"})
+	}
+
+	return sorts, nil
+}
+
+// validateSortFields checks if all sort fields are present in the field map.
+// Returns an error if any field is missing.
+func validateSortFields(fmap map[string]string, sorts []*Sort) error {
+	missingFields := collection.Filter(sorts, func(s *Sort) bool {
+		_, exists := fmap[s.Field]
+		return !exists
+	})
+	if len(missingFields) > 0 {
+		return fmt.Errorf(
+			"missing field mappings in sort map: %v",
+			collection.Map(missingFields, func(s *Sort) string {
+				return s.Field
+			}),
+		)
+	}
+
+	return nil
+}
+
+// GenQualifiedAQLSortStatement generates an AQL SORT statement where the
+// fields map is expected to contain namespaced (fully qualified) mapping to
+// database fields, similar to GenQualifiedAQLFilterStatement, for example
+//
+//	SORT doc.created_at DESC, doc.name ASC
+//
+// Parameters:
+//   - fmap: A map of field names to their fully qualified database field paths
+//   - sorts: A slice of Sort structures containing the sort criteria
+func GenQualifiedAQLSortStatement(
+	fmap map[string]string,
+	sorts []*Sort,
+) (string, error) {
+	if err := validate.Struct(&AQLSortParams{
+		Fmap:  fmap,
+		Sorts: sorts,
+	}); err != nil {
+		return "", fmt.Errorf("invalid parameters: %w", err)
+	}
+	if err := validateSortFields(fmap, sorts); err != nil {
+		return "", err
+	}
+
+	return toSortStatement(collection.Map(sorts, func(s *Sort) string {
+		return fmt.Sprintf("%s %s", fmap[s.Field], sortDirection(s))
+	})), nil
+}
+
+// GenAQLSortStatement generates an AQL SORT statement from the provided
+// SortParameters. Like GenAQLFilterStatement, the fields are expected to be
+// mapped to non-qualified database field names, which are then prefixed with
+// the document or the vertex variable.
+//
+// Returns the generated AQL sort statement as a string and any error encountered.
+func GenAQLSortStatement(prms *SortParameters) (string, error) {
+	if err := validate.Struct(prms); err != nil {
+		return "", fmt.Errorf(
+			"validation error in SortParameters: %w",
+			err,
+		)
+	}
+	if err := validateSortFields(prms.Fmap, prms.Sorts); err != nil {
+		return "", err
+	}
+	inner := prms.Doc
+	if len(prms.Vert) > 0 {
+		inner = prms.Vert
+	}
+
+	return toSortStatement(collection.Map(prms.Sorts, func(s *Sort) string {
+		return fmt.Sprintf(
+			"%s.%s %s",
+			inner, prms.Fmap[s.Field], sortDirection(s),
+		)
+	})), nil
+}
+
+func sortDirection(s *Sort) string {
+	if s.Descending {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+func toSortStatement(clauses []string) string {
+	return fmt.Sprintf("SORT %s", strings.Join(clauses, ", "))
+}
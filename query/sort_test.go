@@ -0,0 +1,92 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSortString(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srt, err := ParseSortString("-created_at, name,+email")
+	assert.NoError(err, "should not return any parse error")
+	assert.Len(srt, 3, "should have three items in sort array")
+	assert.Equal(srt[0].Field, "created_at", "should match field created_at")
+	assert.True(srt[0].Descending, "should be sorted in descending order")
+	assert.Equal(srt[1].Field, "name", "should match field name")
+	assert.False(srt[1].Descending, "should be sorted in ascending order")
+	assert.Equal(srt[2].Field, "email", "should match field email")
+	assert.False(srt[2].Descending, "should be sorted in ascending order")
+
+	empty, err := ParseSortString("")
+	assert.NoError(err, "should not return any parse error")
+	assert.Len(empty, 0, "should have empty slice for empty sort string")
+
+	_, err = ParseSortString("name,,email")
+	assert.Error(err, "should return error for empty sort field")
+	_, err = ParseSortString("--name")
+	assert.Error(err, "should return error for malformed sort field")
+	_, err = ParseSortString("name==doe")
+	assert.Error(err, "should return error for filter like sort field")
+}
+
+func TestGenQualifiedAQLSortStatement(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srt, err := ParseSortString("-created_at,email")
+	assert.NoError(err, "should not return any parse error")
+	stmt, err := GenQualifiedAQLSortStatement(qmap, srt)
+	assert.NoError(err, "should not return any error when generating AQL sort statement")
+	assert.Equal(
+		stmt,
+		"SORT foo.created_at DESC, fizz.identifier ASC",
+		"should match sort statement",
+	)
+	_, err = GenQualifiedAQLSortStatement(
+		qmap,
+		[]*Sort{{Field: "birthday"}},
+	)
+	assert.Error(err, "should return error for unknown field")
+	assert.Contains(err.Error(), "birthday", "error should contain the unknown field")
+	_, err = GenQualifiedAQLSortStatement(qmap, []*Sort{})
+	assert.Error(err, "should return error for empty sort slice")
+}
+
+func TestGenAQLSortStatement(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srt, err := ParseSortString("-created_at,+label")
+	assert.NoError(err, "should not return any parse error")
+	stmt, err := GenAQLSortStatement(
+		&SortParameters{Fmap: fmap, Sorts: srt, Doc: "doc"},
+	)
+	assert.NoError(err, "should not return any error when generating AQL sort statement")
+	assert.Equal(
+		stmt,
+		"SORT doc.created_at DESC, doc.label ASC",
+		"should match sort statement",
+	)
+	vstmt, err := GenAQLSortStatement(
+		&SortParameters{Fmap: fmap, Sorts: srt, Doc: "doc", Vert: "v"},
+	)
+	assert.NoError(err, "should not return any error when generating AQL sort statement")
+	assert.Equal(
+		vstmt,
+		"SORT v.created_at DESC, v.label ASC",
+		"should use the vertex variable",
+	)
+	_, err = GenAQLSortStatement(
+		&SortParameters{
+			Fmap:  fmap,
+			Sorts: []*Sort{{Field: "unknown", Descending: true}},
+			Doc:   "doc",
+		},
+	)
+	assert.Error(err, "should return error for unknown field")
+	assert.Contains(
+		err.Error(),
+		"missing field mappings in sort map",
+		"error should mention missing field mappings",
+	)
+}
@@ -0,0 +1,254 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	vertexVar = "v"
+	edgeVar   = "e"
+	pathVar   = "p"
+)
+
+// PathCondition is a condition that has to hold for the vertices or the
+// edges along the traversed path, i.e. "p.edges[*].weight ALL > 5".
+type PathCondition struct {
+	// Elements of the path to check, either "vertices" or "edges"
+	Target string `validate:"required,oneof=vertices edges"`
+	// How many of the elements should match, one of ALL, ANY or NONE
+	Quantifier string `validate:"required,oneof=ALL ANY NONE"`
+	// Filter applied to the elements, mapped with the vertex or edge
+	// field map, only comparison and date operators are allowed
+	Filter *Filter `validate:"required"`
+}
+
+// Traversal is a container for the elements needed to build a graph
+// traversal query, i.e.
+//
+//	FOR v, e, p IN @min..@max OUTBOUND @start GRAPH @graph
+//		PRUNE ...
+//		FILTER ...
+//		RETURN v
+//
+// The vertex, edge and path variables are always named v, e and p.
+type Traversal struct {
+	// Document handle (_id) of the start vertex
+	Start string `validate:"required"`
+	// Direction of the traversal, one of OUTBOUND, INBOUND or ANY
+	Direction string `validate:"required,oneof=OUTBOUND INBOUND ANY"`
+	// Minimal depth of the traversal
+	MinDepth int `validate:"gte=0"`
+	// Maximal depth of the traversal
+	MaxDepth int `validate:"gtefield=MinDepth"`
+	// Name of the graph to traverse
+	Graph string `validate:"required_without=EdgeCollections,excluded_with=EdgeCollections"`
+	// Edge collections to traverse, when no named graph is used
+	EdgeCollections []string `validate:"required_without=Graph,dive,required"`
+	// Map of vertex filter fields to vertex attributes
+	VertexFmap map[string]string
+	// Filters applied to the vertices
+	VertexFilters []*Filter `validate:"dive"`
+	// Map of edge filter fields to edge attributes
+	EdgeFmap map[string]string
+	// Filters applied to the edges
+	EdgeFilters []*Filter `validate:"dive"`
+	// Filters on the vertices that stop the traversal from going
+	// any deeper once they match, mapped with the vertex field map
+	Prune []*Filter `validate:"dive"`
+	// Conditions on the whole path
+	PathConditions []*PathCondition `validate:"dive"`
+	// Expression to return, defaults to the vertex variable
+	Return string
+}
+
+// Build generates the AQL traversal query along with its bind parameters.
+// The vertex and edge filters are generated with GenAQLFilterStatement
+// and any LET statement they produce is placed after the PRUNE statement.
+func (trv *Traversal) Build() (*Statement, error) {
+	if err := validate.Struct(trv); err != nil {
+		return nil, fmt.Errorf("validation error in Traversal: %w", err)
+	}
+	stmt := &Statement{
+		BindVars: map[string]interface{}{
+			"start": trv.Start,
+			"min":   trv.MinDepth,
+			"max":   trv.MaxDepth,
+		},
+	}
+	clauses := []string{
+		fmt.Sprintf(
+			"FOR %s, %s, %s IN @min..@max %s @start %s",
+			vertexVar, edgeVar, pathVar, trv.Direction,
+			trv.target(stmt.BindVars),
+		),
+	}
+	pruneStmt, err := trv.pruneStatement()
+	if err != nil {
+		return nil, err
+	}
+	clauses = append(clauses, pruneStmt)
+	vertStmt, err := traversalFilter(trv.VertexFmap, trv.VertexFilters, vertexVar)
+	if err != nil {
+		return nil, err
+	}
+	edgeStmt, err := traversalFilter(trv.EdgeFmap, trv.EdgeFilters, edgeVar)
+	if err != nil {
+		return nil, err
+	}
+	pathStmt, err := trv.pathStatement()
+	if err != nil {
+		return nil, err
+	}
+	ret := trv.Return
+	if len(ret) == 0 {
+		ret = vertexVar
+	}
+	stmt.Query = joinClauses(append(
+		clauses, vertStmt, edgeStmt, pathStmt,
+		fmt.Sprintf("RETURN %s", ret),
+	))
+
+	return stmt, nil
+}
+
+// target generates the named graph or the list of edge collections to
+// traverse, adding their bind parameters.
+func (trv *Traversal) target(bindVars map[string]interface{}) string {
+	if len(trv.Graph) > 0 {
+		bindVars["graph"] = trv.Graph
+
+		return "GRAPH @graph"
+	}
+	colls := make([]string, 0, len(trv.EdgeCollections))
+	for idx, coll := range trv.EdgeCollections {
+		name := fmt.Sprintf("edge%d", idx)
+		bindVars["@"+name] = coll
+		colls = append(colls, "@@"+name)
+	}
+
+	return strings.Join(colls, ", ")
+}
+
+// traversalFilter generates the FILTER statement for the vertex or the
+// edge variable, the LET variables are prefixed with the variable so that
+// the names stay unique within the query.
+func traversalFilter(
+	fmap map[string]string,
+	filters []*Filter,
+	doc string,
+) (string, error) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+	if err := validateFilterFields(fmap, filters); err != nil {
+		return "", err
+	}
+
+	return GenAQLFilterStatement(
+		&StatementParameters{Fmap: fmap, Filters: filters, Doc: doc},
+		WithVarNamer(func(idx int, flt *Filter) string {
+			return fmt.Sprintf("%s_%s", doc, HashedVarNamer(idx, flt))
+		}),
+	)
+}
+
+// pruneStatement generates the PRUNE statement, as no LET statement is
+// allowed before it, all the conditions are generated inline.
+func (trv *Traversal) pruneStatement() (string, error) {
+	if len(trv.Prune) == 0 {
+		return "", nil
+	}
+	if err := validateFilterFields(trv.VertexFmap, trv.Prune); err != nil {
+		return "", err
+	}
+	expr, err := inlineExpression(trv.Prune, func(flt *Filter) (string, error) {
+		return genInlineArrayExpr(
+			fmt.Sprintf("%s.%s", vertexVar, trv.VertexFmap[flt.Field]),
+			flt,
+		)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("PRUNE %s", expr), nil
+}
+
+func (trv *Traversal) pathStatement() (string, error) {
+	if len(trv.PathConditions) == 0 {
+		return "", nil
+	}
+	conds := make([]string, 0, len(trv.PathConditions))
+	for _, pcd := range trv.PathConditions {
+		cond, err := trv.pathCondition(pcd)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+
+	return fmt.Sprintf("FILTER %s", strings.Join(conds, "\n AND ")), nil
+}
+
+func (trv *Traversal) pathCondition(pcd *PathCondition) (string, error) {
+	fmap := trv.VertexFmap
+	if pcd.Target == "edges" {
+		fmap = trv.EdgeFmap
+	}
+	flt := pcd.Filter
+	attr, ok := fmap[flt.Field]
+	if !ok {
+		return "", fmt.Errorf("missing path condition field %s in field map", flt.Field)
+	}
+	opr := getOperator(flt.Operator)
+	switch {
+	case hasArrayOperator(flt.Operator), opr == "=~", opr == "!~", len(opr) == 0:
+		return "", fmt.Errorf(
+			"operator %s is not allowed in path conditions",
+			flt.Operator,
+		)
+	case hasDateOperator(flt.Operator):
+		if err := dateValidator(flt.Value); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf(
+			"%s.%s[*].%s %s %s DATE_ISO8601('%s')",
+			pathVar, pcd.Target, attr, pcd.Quantifier, opr, flt.Value,
+		), nil
+	default:
+		return fmt.Sprintf(
+			"%s.%s[*].%s %s %s %s",
+			pathVar, pcd.Target, attr, pcd.Quantifier, opr,
+			addQuoteToStrings(flt.Operator, flt.Value),
+		), nil
+	}
+}
+
+// inlineExpression combines the conditions of the filters into a single
+// boolean expression, following the grouping of toConjunction.
+func inlineExpression(
+	filters []*Filter,
+	condFn func(*Filter) (string, error),
+) (string, error) {
+	groups := toConjunction(filters)
+	clauses := make([]string, 0, len(groups))
+	for _, grp := range groups {
+		conds := make([]string, 0, len(grp))
+		for _, flt := range grp {
+			cond, err := condFn(flt)
+			if err != nil {
+				return "", err
+			}
+			conds = append(conds, cond)
+		}
+		if len(conds) == 1 {
+			clauses = append(clauses, conds[0])
+			continue
+		}
+		clauses = append(clauses, fmt.Sprintf("(%s)", strings.Join(conds, " OR ")))
+	}
+
+	return strings.Join(clauses, " AND "), nil
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	vertexMap = map[string]string{
+		"name":   "name",
+		"tag":    "tags",
+		"status": "status",
+	}
+	edgeMap = map[string]string{
+		"type":    "type",
+		"weight":  "weight",
+		"created": "created_at",
+	}
+)
+
+func TestTraversalBuild(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	vfls, err := ParseFilterString("status==active;tag@==remi")
+	assert.NoError(err, "should not return any parse error")
+	efls, err := ParseFilterString("type==part_of,type==is_a")
+	assert.NoError(err, "should not return any parse error")
+	pfls, err := ParseFilterString("status==obsolete")
+	assert.NoError(err, "should not return any parse error")
+	trv := &Traversal{
+		Start:         "terms/123",
+		Direction:     "OUTBOUND",
+		MinDepth:      1,
+		MaxDepth:      3,
+		Graph:         "ontology",
+		VertexFmap:    vertexMap,
+		VertexFilters: vfls,
+		EdgeFmap:      edgeMap,
+		EdgeFilters:   efls,
+		Prune:         pfls,
+		PathConditions: []*PathCondition{
+			{
+				Target:     "edges",
+				Quantifier: "ALL",
+				Filter:     &Filter{Field: "weight", Operator: ">", Value: "5"},
+			},
+			{
+				Target:     "vertices",
+				Quantifier: "NONE",
+				Filter:     &Filter{Field: "name", Operator: "==", Value: "root"},
+			},
+		},
+	}
+	stmt, err := trv.Build()
+	assert.NoError(err, "should build the traversal")
+	tagVar := "v_" + HashedVarNamer(1, vfls[1])
+	assert.Equal(
+		`FOR v, e, p IN @min..@max OUTBOUND @start GRAPH @graph
+	PRUNE v.status == 'obsolete'
+	LET `+tagVar+` = (
+				FILTER 'remi' IN v.tags[*] `+"\n"+`				RETURN 1
+		)
+	FILTER v.status == 'active'
+ AND LENGTH(`+tagVar+`) > 0
+	FILTER  ( e.type == 'part_of'
+ OR e.type == 'is_a' )
+	FILTER p.edges[*].weight ALL > 5
+ AND p.vertices[*].name NONE == 'root'
+	RETURN v`,
+		stmt.Query,
+		"should match the traversal query",
+	)
+	assert.Equal(
+		map[string]interface{}{
+			"start": "terms/123",
+			"min":   1,
+			"max":   3,
+			"graph": "ontology",
+		},
+		stmt.BindVars,
+		"should match the bind parameters",
+	)
+}
+
+func TestTraversalEdgeCollections(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	trv := &Traversal{
+		Start:           "terms/123",
+		Direction:       "ANY",
+		MaxDepth:        2,
+		EdgeCollections: []string{"term_relation", "term_synonym"},
+		EdgeFmap:        edgeMap,
+		Prune: []*Filter{
+			{Field: "tag", Operator: "@==", Value: "leaf", Logic: ","},
+			{Field: "name", Operator: "=~", Value: "root"},
+		},
+		VertexFmap: vertexMap,
+		PathConditions: []*PathCondition{{
+			Target:     "edges",
+			Quantifier: "ANY",
+			Filter:     &Filter{Field: "created", Operator: "$>=", Value: "2020"},
+		}},
+		Return: "p",
+	}
+	stmt, err := trv.Build()
+	assert.NoError(err, "should build the traversal")
+	assert.Equal(
+		`FOR v, e, p IN @min..@max ANY @start @@edge0, @@edge1
+	PRUNE ('leaf' IN v.tags[*] OR v.name =~ 'root')
+	FILTER p.edges[*].created_at ANY >= DATE_ISO8601('2020')
+	RETURN p`,
+		stmt.Query,
+		"should traverse the edge collections",
+	)
+	assert.Equal(
+		"term_synonym",
+		stmt.BindVars["@edge1"],
+		"should bind the edge collections",
+	)
+}
+
+func TestTraversalErrors(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	for name, trv := range map[string]*Traversal{
+		"missing target": {
+			Start: "terms/1", Direction: "OUTBOUND", MaxDepth: 1,
+		},
+		"graph and edge collections": {
+			Start: "terms/1", Direction: "OUTBOUND", MaxDepth: 1,
+			Graph: "ontology", EdgeCollections: []string{"term_relation"},
+		},
+		"invalid direction": {
+			Start: "terms/1", Direction: "SIDEWAYS", MaxDepth: 1, Graph: "ontology",
+		},
+		"invalid depth": {
+			Start: "terms/1", Direction: "OUTBOUND", MinDepth: 3, MaxDepth: 1,
+			Graph: "ontology",
+		},
+		"unmapped vertex filter": {
+			Start: "terms/1", Direction: "OUTBOUND", MaxDepth: 1, Graph: "ontology",
+			VertexFmap:    vertexMap,
+			VertexFilters: []*Filter{{Field: "weight", Operator: "==", Value: "1"}},
+		},
+		"regex path condition": {
+			Start: "terms/1", Direction: "OUTBOUND", MaxDepth: 1, Graph: "ontology",
+			EdgeFmap: edgeMap,
+			PathConditions: []*PathCondition{{
+				Target: "edges", Quantifier: "ALL",
+				Filter: &Filter{Field: "type", Operator: "=~", Value: "part"},
+			}},
+		},
+	} {
+		_, err := trv.Build()
+		assert.Errorf(err, "should return error for %s", name)
+	}
+}
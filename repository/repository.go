@@ -0,0 +1,282 @@
+// Package repository provides a generic repository for the documents of a
+// single collection, built on the document helpers of arangomanager and the
+// list queries of the query package.
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dictyBase/arangomanager"
+	"github.com/dictyBase/arangomanager/query"
+	validator "github.com/go-playground/validator/v10"
+)
+
+const (
+	defaultDoc   = "doc"
+	defaultLimit = 10
+	defaultMax   = 100
+)
+
+// Options are the options of a repository, nil is the same as the zero
+// value.
+type Options struct {
+	// The variable used for looping inside the collection, doc if empty
+	Doc string `validate:"omitempty,alpha"`
+	// Page size used when a list request has no limit, 10 if zero
+	DefaultLimit int `validate:"gte=0"`
+	// Largest page size a list request could ask for, 100 if zero
+	MaxLimit int `validate:"gte=0"`
+	// Sort string used when a list request has no order
+	DefaultSort string
+	// Policy the filters of list and count have to conform to, it is
+	// validated by the query package. The operators allowed by the arango
+	// tags of the document are used if it is nil.
+	Policy *query.FilterPolicy `validate:"-"`
+	// Secret for signing page tokens, setting it switches List to keyset
+	// pagination
+	TokenSecret []byte
+	// Fmap adds to or overrides the filter fields derived from the tags of
+	// the document, the fields it adds are neither restricted by the
+	// policy of the tags nor by their sort options
+	Fmap map[string]string
+}
+
+// Repository gives access to the documents of type T stored in a
+// collection.
+type Repository[T any] struct {
+	dbh        *arangomanager.Database
+	collection string
+	schema     *query.ListSchema
+}
+
+// New returns the repository for the collection, T has to be a structure.
+// The filter and sort fields of List and Count are built from the arango and
+// json tags of T by query.FieldMapFor, including the fields of embedded
+// structures such as driver.DocumentMeta. Untagged fields are neither
+// sortable nor filtered with regex operators.
+func New[T any](
+	dbh *arangomanager.Database,
+	collection string,
+	opts *Options,
+) (*Repository[T], error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := validator.New().Struct(opts); err != nil {
+		return nil, fmt.Errorf("invalid repository options %w", err)
+	}
+	if len(collection) == 0 {
+		return nil, fmt.Errorf("empty collection name")
+	}
+	if typ := reflect.TypeOf((*T)(nil)).Elem(); typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("document type %s is not a structure", typ)
+	}
+	fields, err := query.FieldMapFor[T]()
+	if err != nil {
+		return nil, err
+	}
+	fmap := fields.Fmap()
+	sortable := fields.SortFields()
+	policy := opts.Policy
+	if policy == nil {
+		policy = fields.Policy()
+	}
+	for field, dbField := range opts.Fmap {
+		if _, ok := fmap[field]; !ok {
+			sortable = append(sortable, field)
+			if opts.Policy == nil {
+				policy.Fields[field] = &query.FieldPolicy{AllowRegex: true}
+			}
+		}
+		fmap[field] = dbField
+	}
+	schema := &query.ListSchema{
+		Collection:   collection,
+		Doc:          defaultDoc,
+		Fmap:         fmap,
+		Policy:       policy,
+		DefaultLimit: defaultLimit,
+		MaxLimit:     defaultMax,
+		DefaultSort:  opts.DefaultSort,
+		Sortable:     sortable,
+		TokenSecret:  opts.TokenSecret,
+	}
+	if len(opts.Doc) > 0 {
+		schema.Doc = opts.Doc
+	}
+	if opts.DefaultLimit > 0 {
+		schema.DefaultLimit = opts.DefaultLimit
+	}
+	if opts.MaxLimit > 0 {
+		schema.MaxLimit = opts.MaxLimit
+	}
+	// an empty request checks the schema and the default sort
+	if _, err := query.FromListRequest(&query.ListRequest{}, schema); err != nil {
+		return nil, fmt.Errorf("invalid repository for %s %w", collection, err)
+	}
+
+	return &Repository[T]{dbh: dbh, collection: collection, schema: schema}, nil
+}
+
+// Collection returns the name of the collection.
+func (r *Repository[T]) Collection() string {
+	return r.collection
+}
+
+// FieldMap returns a copy of the map of filter and sort fields to database
+// fields.
+func (r *Repository[T]) FieldMap() map[string]string {
+	fmap := make(map[string]string, len(r.schema.Fmap))
+	for field, dbField := range r.schema.Fmap {
+		fmap[field] = dbField
+	}
+
+	return fmap
+}
+
+// Insert creates the document, see arangomanager.Insert.
+func (r *Repository[T]) Insert(
+	ctx context.Context,
+	doc T,
+	opts *arangomanager.DocOptions,
+) (*arangomanager.DocumentResult[T], error) {
+	return arangomanager.Insert(ctx, r.dbh, r.collection, doc, opts)
+}
+
+// InsertMany creates the documents with a single request, see
+// arangomanager.InsertMany.
+func (r *Repository[T]) InsertMany(
+	ctx context.Context,
+	docs []T,
+	opts *arangomanager.DocOptions,
+) ([]*arangomanager.DocumentResult[T], error) {
+	return arangomanager.InsertMany(ctx, r.dbh, r.collection, docs, opts)
+}
+
+// Get reads the document with the key.
+func (r *Repository[T]) Get(ctx context.Context, key string) (T, error) {
+	return arangomanager.Get[T](ctx, r.dbh, r.collection, key)
+}
+
+// ReadMany reads the documents with the keys with a single request, see
+// arangomanager.ReadMany.
+func (r *Repository[T]) ReadMany(
+	ctx context.Context,
+	keys []string,
+) ([]T, error) {
+	return arangomanager.ReadMany[T](ctx, r.dbh, r.collection, keys)
+}
+
+// Update partially updates the document with the key, update holds the
+// attributes to change.
+func (r *Repository[T]) Update(
+	ctx context.Context,
+	key string,
+	update interface{},
+	opts *arangomanager.DocOptions,
+) (*arangomanager.DocumentResult[T], error) {
+	return arangomanager.Update[T](ctx, r.dbh, r.collection, key, update, opts)
+}
+
+// Replace replaces the document with the key.
+func (r *Repository[T]) Replace(
+	ctx context.Context,
+	key string,
+	doc T,
+	opts *arangomanager.DocOptions,
+) (*arangomanager.DocumentResult[T], error) {
+	return arangomanager.Replace(ctx, r.dbh, r.collection, key, doc, opts)
+}
+
+// Remove removes the document with the key.
+func (r *Repository[T]) Remove(
+	ctx context.Context,
+	key string,
+	opts *arangomanager.DocOptions,
+) (*arangomanager.DocumentResult[T], error) {
+	return arangomanager.Remove[T](ctx, r.dbh, r.collection, key, opts)
+}
+
+// RemoveMany removes the documents with the keys with a single request, see
+// arangomanager.RemoveMany.
+func (r *Repository[T]) RemoveMany(
+	ctx context.Context,
+	keys []string,
+	opts *arangomanager.DocOptions,
+) error {
+	return arangomanager.RemoveMany(ctx, r.dbh, r.collection, keys, opts)
+}
+
+// Upsert inserts the document or updates the one matching it on the
+// matchFields attributes, see arangomanager.Upsert.
+func (r *Repository[T]) Upsert(
+	ctx context.Context,
+	matchFields []string,
+	doc T,
+	opts *arangomanager.UpsertOptions,
+) (*arangomanager.UpsertResult, error) {
+	return arangomanager.Upsert(ctx, r.dbh, r.collection, matchFields, doc, opts)
+}
+
+// UpsertMany upserts the documents in batches, see arangomanager.UpsertMany.
+func (r *Repository[T]) UpsertMany(
+	ctx context.Context,
+	matchFields []string,
+	docs []T,
+	opts *arangomanager.UpsertOptions,
+) (*arangomanager.UpsertResult, error) {
+	return arangomanager.UpsertMany(ctx, r.dbh, r.collection, matchFields, docs, opts)
+}
+
+// Exists checks if the document with the key exists.
+func (r *Repository[T]) Exists(ctx context.Context, key string) (bool, error) {
+	return arangomanager.Exists(ctx, r.dbh, r.collection, key)
+}
+
+// List returns a page of the documents matching the list request. Invalid
+// parameters of the request are reported as a *query.RequestError.
+func (r *Repository[T]) List(
+	ctx context.Context,
+	req *query.ListRequest,
+) (*query.Page[T], error) {
+	stmt, err := query.FromListRequest(req, r.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return query.QueryAllContext[T](ctx, r.dbh, stmt)
+}
+
+// Count returns the number of documents matching the filter string, every
+// document is counted if it is empty.
+func (r *Repository[T]) Count(
+	ctx context.Context,
+	filter string,
+) (int64, error) {
+	stmt, err := (&query.Aggregation{
+		Collection: r.collection,
+		Doc:        r.schema.Doc,
+		Fmap:       r.schema.Fmap,
+		Filter:     strings.TrimSpace(filter),
+		Policy:     r.schema.Policy,
+	}).Build()
+	if err != nil {
+		return 0, &query.RequestError{Param: query.FilterParam, Err: err}
+	}
+	row, err := r.dbh.GetRowContext(ctx, stmt.Query, stmt.BindVars)
+	if err != nil {
+		return 0, err
+	}
+	if row.IsEmpty() {
+		return 0, nil
+	}
+	fct := &query.Facet{}
+	if err := row.Read(fct); err != nil {
+		return 0, err
+	}
+
+	return fct.Count, nil
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+	driverhttp "github.com/arangodb/go-driver/http"
+	"github.com/dictyBase/arangomanager"
+	"github.com/dictyBase/arangomanager/query"
+	"github.com/stretchr/testify/require"
+)
+
+const standInDB = "standin"
+
+type stockItem struct {
+	driver.DocumentMeta
+	Name     string `json:"name" arango:",sort"`
+	Count    int    `json:"count,omitempty"`
+	Location string
+	Color    string    `json:"color" arango:"props.color,filter=eq"`
+	Created  time.Time `json:"created_at"`
+	Internal string    `json:"-"`
+	note     string
+}
+
+// queryServer is a stand-in server for the AQL queries and the single
+// document reads of a collection named stock.
+type queryServer struct {
+	mu       sync.Mutex
+	rows     []interface{}
+	queries  []string
+	bindVars []map[string]interface{}
+}
+
+func (srv *queryServer) handle(wrt http.ResponseWriter, req *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	route := strings.TrimPrefix(req.URL.Path, "/_db/"+standInDB+"/_api/")
+	switch {
+	case route == "database/current":
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200,
+			"result": map[string]interface{}{"name": standInDB, "id": "1"},
+		})
+	case route == "collection/stock":
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200, "name": "stock", "type": 2,
+		})
+	case route == "document/stock/gel" && req.Method == http.MethodGet:
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"_key": "gel", "_id": "stock/gel", "_rev": "_r1", "name": "gel",
+		})
+	case route == "document/stock/gel" && req.Method == http.MethodHead:
+		wrt.WriteHeader(http.StatusOK)
+	case route == "query" && req.Method == http.MethodPost:
+		var body map[string]string
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		params := make([]string, 0)
+		for _, word := range strings.FieldsFunc(body["query"], func(r rune) bool {
+			return !(r == '@' || r == '_' || r >= '0' && r <= '9' ||
+				r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		}) {
+			if strings.HasPrefix(word, "@") {
+				params = append(params, strings.TrimPrefix(word, "@"))
+			}
+		}
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200, "parsed": true,
+			"collections": []string{}, "bindVars": params, "ast": []interface{}{},
+		})
+	case route == "cursor" && req.Method == http.MethodPost:
+		var body struct {
+			Query    string                 `json:"query"`
+			BindVars map[string]interface{} `json:"bindVars"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		srv.queries = append(srv.queries, body.Query)
+		srv.bindVars = append(srv.bindVars, body.BindVars)
+		writeJSON(wrt, http.StatusCreated, map[string]interface{}{
+			"error": false, "code": 201, "result": srv.rows,
+			"hasMore": false, "count": len(srv.rows),
+		})
+	default:
+		writeJSON(wrt, http.StatusNotFound, map[string]interface{}{
+			"error": true, "code": 404, "errorNum": 1202,
+			"errorMessage": "document not found",
+		})
+	}
+}
+
+func writeJSON(wrt http.ResponseWriter, status int, body interface{}) {
+	wrt.Header().Set("Content-Type", "application/json")
+	wrt.WriteHeader(status)
+	_ = json.NewEncoder(wrt).Encode(body)
+}
+
+func newStandInRepository(
+	t *testing.T,
+	srv *queryServer,
+	opts *Options,
+) *Repository[stockItem] {
+	t.Helper()
+	assert := require.New(t)
+	hsrv := httptest.NewServer(http.HandlerFunc(srv.handle))
+	t.Cleanup(hsrv.Close)
+	conn, err := driverhttp.NewConnection(
+		driverhttp.ConnectionConfig{Endpoints: []string{hsrv.URL}},
+	)
+	assert.NoError(err, "should create connection to stand-in server")
+	client, err := driver.NewClient(driver.ClientConfig{Connection: conn})
+	assert.NoError(err, "should create client of stand-in server")
+	dbh, err := arangomanager.NewSessionFromClient(client).DB(standInDB)
+	assert.NoError(err, "should get the stand-in database")
+	repo, err := New[stockItem](dbh, "stock", opts)
+	assert.NoError(err, "should create the repository")
+
+	return repo
+}
+
+func TestNewFieldMap(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	repo, err := New[stockItem](nil, "stock", &Options{
+		Fmap: map[string]string{"location": "Location"},
+	})
+	assert.NoError(err, "should create the repository")
+	assert.Equal("stock", repo.Collection(), "should match the collection")
+	assert.Equal(map[string]string{
+		"_key":       "_key",
+		"_id":        "_id",
+		"name":       "name",
+		"count":      "count",
+		"Location":   "Location",
+		"location":   "Location",
+		"color":      "props.color",
+		"created_at": "created_at",
+	}, repo.FieldMap(), "should map the json names of the fields")
+	repo.FieldMap()["name"] = "label"
+	assert.Equal("name", repo.FieldMap()["name"], "should return a copy of the field map")
+}
+
+func TestNewInvalid(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	_, err := New[string](nil, "stock", nil)
+	assert.Error(err, "should return error for a non structure document")
+	_, err = New[*stockItem](nil, "stock", nil)
+	assert.Error(err, "should return error for a pointer document")
+	_, err = New[stockItem](nil, "", nil)
+	assert.Error(err, "should return error for an empty collection name")
+	_, err = New[stockItem](nil, "stock", &Options{DefaultSort: "-price"})
+	assert.Error(err, "should return error for an unknown default sort field")
+	_, err = New[stockItem](nil, "stock", &Options{DefaultLimit: 50, MaxLimit: 20})
+	assert.Error(err, "should return error for a default limit above the maximum")
+	_, err = New[stockItem](nil, "stock", &Options{Doc: "doc 1"})
+	assert.Error(err, "should return error for an invalid loop variable")
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &queryServer{rows: []interface{}{
+		map[string]interface{}{"_key": "gel", "name": "gel", "count": 4},
+		map[string]interface{}{"_key": "tip", "name": "tip", "count": 2},
+	}}
+	repo := newStandInRepository(t, srv, &Options{DefaultSort: "name"})
+	page, err := repo.List(context.Background(), &query.ListRequest{
+		Filter: "count>1", Limit: 5,
+	})
+	assert.NoError(err, "should list the documents")
+	assert.Len(page.Items, 2, "should return the documents of the page")
+	assert.Equal("tip", page.Items[1].Name, "should decode the documents")
+	assert.Equal("gel", page.Items[0].Key, "should fill the DocumentMeta")
+	assert.Len(srv.queries, 1, "should run a single query")
+	assert.Contains(srv.queries[0], "FILTER doc.count > 1", "should filter the documents")
+	assert.Contains(srv.queries[0], "SORT doc.name ASC", "should sort by the default sort")
+	assert.Equal("stock", srv.bindVars[0]["@collection"], "should loop over the collection")
+	assert.EqualValues(5, srv.bindVars[0]["limit"], "should use the limit of the request")
+
+	_, err = repo.List(context.Background(), &query.ListRequest{Filter: "price>1"})
+	var rerr *query.RequestError
+	assert.ErrorAs(err, &rerr, "should return a RequestError for an unknown field")
+	assert.Equal(query.FilterParam, rerr.Param, "should report the filter parameter")
+	_, err = repo.List(context.Background(), &query.ListRequest{Limit: 500})
+	assert.ErrorAs(err, &rerr, "should return a RequestError for a limit above the maximum")
+	_, err = repo.List(context.Background(), &query.ListRequest{OrderBy: "color"})
+	assert.ErrorAs(err, &rerr, "should return a RequestError for a field without sort option")
+	assert.Equal(query.OrderByParam, rerr.Param, "should report the order_by parameter")
+	_, err = repo.List(context.Background(), &query.ListRequest{OrderBy: "count"})
+	assert.ErrorAs(err, &rerr, "should return a RequestError for an untagged sort field")
+	_, err = repo.List(context.Background(), &query.ListRequest{Filter: "count=~1"})
+	assert.ErrorAs(err, &rerr, "should return a RequestError for a regex on an untagged field")
+	_, err = repo.List(context.Background(), &query.ListRequest{Filter: "_rev==_r1"})
+	assert.ErrorAs(err, &rerr, "should return a RequestError for the revision field")
+	_, err = repo.List(context.Background(), &query.ListRequest{Filter: "created_at$>2019-13"})
+	assert.True(errors.As(err, &rerr), "should return a RequestError for an invalid date")
+	assert.Equal(query.FilterParam, rerr.Param, "should report the filter parameter for an invalid date")
+	assert.Len(srv.queries, 1, "should not run the query with an invalid date")
+}
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &queryServer{rows: []interface{}{
+		map[string]interface{}{"group": map[string]interface{}{}, "count": 3},
+	}}
+	repo := newStandInRepository(t, srv, nil)
+	total, err := repo.Count(context.Background(), "name===gel")
+	assert.NoError(err, "should count the documents")
+	assert.EqualValues(3, total, "should return the count")
+	assert.Contains(srv.queries[0], "FILTER doc.name == 'gel'", "should filter the documents")
+	assert.Contains(srv.queries[0], "COLLECT WITH COUNT INTO", "should count the documents")
+	_, err = repo.Count(context.Background(), "")
+	assert.NoError(err, "should count every document")
+	assert.NotContains(srv.queries[1], "FILTER", "should not filter the documents")
+	_, err = repo.Count(context.Background(), "color===red")
+	assert.NoError(err, "should count with an operator allowed by the tag")
+	assert.Contains(srv.queries[2], "FILTER doc.props.color == 'red'", "should filter on the tag path")
+	_, err = repo.Count(context.Background(), "color=~red")
+	var perr *query.PolicyError
+	assert.ErrorAs(err, &perr, "should reject an operator not allowed by the tag")
+
+	policy := &query.FilterPolicy{
+		Fields: map[string]*query.FieldPolicy{"count": {}},
+	}
+	repo = newStandInRepository(t, srv, &Options{Policy: policy})
+	_, err = repo.Count(context.Background(), "name===gel")
+	assert.ErrorAs(err, &perr, "should check the filter against the policy")
+	var rerr *query.RequestError
+	assert.ErrorAs(err, &rerr, "should return a RequestError for the rejected filter")
+}
+
+func TestDocuments(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	repo := newStandInRepository(t, &queryServer{}, nil)
+	item, err := repo.Get(ctx, "gel")
+	assert.NoError(err, "should get the document")
+	assert.Equal("gel", item.Name, "should decode the document")
+	assert.Equal("_r1", item.Rev, "should fill the DocumentMeta")
+	_, err = repo.Get(ctx, "box")
+	assert.ErrorIs(err, arangomanager.ErrNotFound, "should return not found error")
+	ok, err := repo.Exists(ctx, "gel")
+	assert.NoError(err, "should check the document")
+	assert.True(ok, "should find the document")
+	ok, err = repo.Exists(ctx, "box")
+	assert.NoError(err, "should check the missing document")
+	assert.False(ok, "should not find the missing document")
+}
@@ -25,6 +25,13 @@ func (r *Result) Read(iface interface{}) error {
 	if err != nil {
 		return fmt.Errorf("error in reading document %s", err)
 	}
+
+	return setDocumentMeta(iface, meta)
+}
+
+// setDocumentMeta assigns the meta to the DocumentMeta field embedded in
+// the structure, any other value is left untouched.
+func setDocumentMeta(iface interface{}, meta driver.DocumentMeta) error {
 	if !structs.IsStruct(iface) {
 		return nil
 	}
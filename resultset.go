@@ -1,11 +1,13 @@
 package arangomanager
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	driver "github.com/arangodb/go-driver"
-	"github.com/fatih/structs"
 )
 
 // Resultset is a cursor for multiple rows of result.
@@ -13,6 +15,13 @@ type Resultset struct {
 	cursor driver.Cursor
 	ctx    context.Context
 	empty  bool
+	last   interface{}
+}
+
+// PageTokenEncoder generates the token of the next page from the last row
+// of the current page.
+type PageTokenEncoder interface {
+	EncodeToken(row map[string]interface{}) (string, error)
 }
 
 // IsEmpty checks for empty resultset.
@@ -46,22 +55,9 @@ func (r *Resultset) Read(iface interface{}) error {
 	if err != nil {
 		return fmt.Errorf("error in reading document %s", err)
 	}
-	if !structs.IsStruct(iface) {
-		return nil
-	}
-	s := structs.New(iface)
-	if f, ok := s.FieldOk("DocumentMeta"); ok {
-		if f.IsEmbedded() {
-			if err := f.Set(meta); err != nil {
-				return fmt.Errorf(
-					"error in assigning DocumentMeta to the structure %s",
-					err,
-				)
-			}
-		}
-	}
+	r.last = iface
 
-	return nil
+	return setDocumentMeta(iface, meta)
 }
 
 // Close closes the resultset and releases resources.
@@ -77,3 +73,33 @@ func (r *Resultset) Close() error {
 
 	return nil
 }
+
+// NextPageToken generates the token of the next page from the row that was
+// read last, which is expected to include all the fields needed by the
+// encoder.
+func (r *Resultset) NextPageToken(enc PageTokenEncoder) (string, error) {
+	if r.last == nil {
+		return "", errors.New("no row has been read from the resultset")
+	}
+	row, err := toRowMap(r.last)
+	if err != nil {
+		return "", err
+	}
+
+	return enc.EncodeToken(row)
+}
+
+func toRowMap(iface interface{}) (map[string]interface{}, error) {
+	ctn, err := json.Marshal(iface)
+	if err != nil {
+		return nil, fmt.Errorf("error in encoding row %s", err)
+	}
+	var row map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(ctn))
+	dec.UseNumber()
+	if err := dec.Decode(&row); err != nil {
+		return nil, fmt.Errorf("error in decoding row %s", err)
+	}
+
+	return row, nil
+}
@@ -78,3 +78,37 @@ func TestResultsetWorkflow(t *testing.T) {
 	assert.NoError(rs2.Close(), "First close should not error")
 	assert.NoError(rs2.Close(), "Second close should not error")
 }
+
+type rowEncoder struct {
+	row map[string]interface{}
+}
+
+func (e *rowEncoder) EncodeToken(row map[string]interface{}) (string, error) {
+	e.row = row
+
+	return "token", nil
+}
+
+// TestResultsetNextPageToken tests the generation of the next page token
+// from the row that was read last
+func TestResultsetNextPageToken(t *testing.T) {
+	assert := require.New(t)
+	enc := &rowEncoder{}
+	rs := &Resultset{empty: true}
+	_, err := rs.NextPageToken(enc)
+	assert.Error(err, "NextPageToken should return error before any read")
+
+	usr := &testUserDb{}
+	usr.Key = "1234"
+	usr.Name.First = "Lue"
+	rs.last = usr
+	token, err := rs.NextPageToken(enc)
+	assert.NoError(err, "NextPageToken should not return error")
+	assert.Equal("token", token, "should return the encoded token")
+	assert.Equal("1234", enc.row["_key"], "row should contain the document key")
+	assert.Equal(
+		map[string]interface{}{"first": "Lue", "last": ""},
+		enc.row["name"],
+		"row should contain the nested attributes",
+	)
+}
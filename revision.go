@@ -0,0 +1,202 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/fatih/structs"
+)
+
+const defaultRevisionRetries = 5
+
+// ErrRevisionConflict is matched by the errors of revision-checked
+// operations on a document that was changed since it was read.
+var ErrRevisionConflict = errors.New("revision conflict")
+
+// RevisionConflictError is returned by the revision-checked helpers when
+// the revision of the stored document differs from the expected one.
+type RevisionConflictError struct {
+	Collection string
+	Key        string
+	// Expected is the revision the operation was based on
+	Expected string
+	// Current is the revision of the stored document, empty if it could
+	// not be read
+	Current string
+	Err     error
+}
+
+func (e *RevisionConflictError) Error() string {
+	return fmt.Sprintf(
+		"revision conflict on document %s/%s, expected revision %s current revision %s",
+		e.Collection, e.Key, e.Expected, e.Current,
+	)
+}
+
+func (e *RevisionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrRevisionConflict.
+func (e *RevisionConflictError) Is(target error) bool {
+	return target == ErrRevisionConflict
+}
+
+// UpdateIfMatch partially updates the document like Update, provided the
+// stored document still has the revision of the DocumentMeta embedded in
+// current. It returns a *RevisionConflictError otherwise.
+func UpdateIfMatch[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	current T,
+	update interface{},
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	meta, err := documentRevision(current)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Update[T](
+		driver.WithRevision(ctx, meta.Rev),
+		dbh, collection, meta.Key, update, opts,
+	)
+
+	return res, revisionError(ctx, dbh, collection, meta, err)
+}
+
+// ReplaceIfMatch replaces the document like Replace, provided the stored
+// document still has the revision of the DocumentMeta embedded in doc. It
+// returns a *RevisionConflictError otherwise.
+func ReplaceIfMatch[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	doc T,
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	meta, err := documentRevision(doc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Replace(
+		driver.WithRevision(ctx, meta.Rev),
+		dbh, collection, meta.Key, doc, opts,
+	)
+
+	return res, revisionError(ctx, dbh, collection, meta, err)
+}
+
+// RemoveIfMatch removes the document like Remove, provided the stored
+// document still has the revision of the DocumentMeta embedded in doc. It
+// returns a *RevisionConflictError otherwise.
+func RemoveIfMatch[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	doc T,
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	meta, err := documentRevision(doc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Remove[T](
+		driver.WithRevision(ctx, meta.Rev),
+		dbh, collection, meta.Key, opts,
+	)
+
+	return res, revisionError(ctx, dbh, collection, meta, err)
+}
+
+// UpdateWithRetry reads the document, applies mutate to it and replaces it
+// with ReplaceIfMatch. On a revision conflict the document is read and
+// mutated again, up to opts.MaxRetries times. An error of mutate stops the
+// update and is returned as is.
+func UpdateWithRetry[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection, key string,
+	mutate func(doc *T) error,
+	opts *DocOptions,
+) (*DocumentResult[T], error) {
+	retries := defaultRevisionRetries
+	if opts != nil && opts.MaxRetries > 0 {
+		retries = opts.MaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		doc, err := Get[T](ctx, dbh, collection, key)
+		if err != nil {
+			return nil, err
+		}
+		meta, err := documentRevision(doc)
+		if err != nil {
+			return nil, err
+		}
+		if err := mutate(&doc); err != nil {
+			return nil, err
+		}
+		// the key and the revision are the ones that were read
+		if err := setDocumentMeta(documentRef(&doc), meta); err != nil {
+			return nil, err
+		}
+		res, err := ReplaceIfMatch(ctx, dbh, collection, doc, opts)
+		if err == nil || attempt >= retries ||
+			!errors.Is(err, ErrRevisionConflict) {
+			return res, err
+		}
+	}
+}
+
+// documentRevision returns the DocumentMeta embedded in the document, which
+// has to include the key and the revision.
+func documentRevision(doc interface{}) (driver.DocumentMeta, error) {
+	var meta driver.DocumentMeta
+	if !structs.IsStruct(doc) {
+		return meta, fmt.Errorf("document of type %T is not a structure", doc)
+	}
+	field, ok := structs.New(doc).FieldOk("DocumentMeta")
+	if !ok || !field.IsEmbedded() {
+		return meta, fmt.Errorf("document of type %T has no embedded DocumentMeta", doc)
+	}
+	meta, ok = field.Value().(driver.DocumentMeta)
+	if !ok {
+		return meta, fmt.Errorf("document of type %T has no embedded DocumentMeta", doc)
+	}
+	if meta.Key == "" || meta.Rev == "" {
+		return meta, fmt.Errorf("document has no key or revision")
+	}
+
+	return meta, nil
+}
+
+// revisionError converts a failed precondition of the server into a
+// RevisionConflictError, with the current revision of the document.
+func revisionError(
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	meta driver.DocumentMeta,
+	err error,
+) error {
+	var aerr driver.ArangoError
+	if !errors.As(err, &aerr) || aerr.Code != http.StatusPreconditionFailed {
+		return err
+	}
+	rerr := &RevisionConflictError{
+		Collection: collection,
+		Key:        meta.Key,
+		Expected:   meta.Rev,
+		Err:        err,
+	}
+	if current, cerr := Get[map[string]interface{}](
+		ctx, dbh, collection, meta.Key,
+	); cerr == nil {
+		rerr.Current, _ = current["_rev"].(string)
+	}
+
+	return rerr
+}
@@ -0,0 +1,105 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/stretchr/testify/require"
+)
+
+func insertStockItem(t *testing.T, dbh *Database) stockItem {
+	t.Helper()
+	res, err := Insert(context.Background(), dbh, "stock", &stockItem{
+		DocumentMeta: driver.DocumentMeta{Key: "gel"}, Name: "gel", Count: 4,
+	}, &DocOptions{ReturnNew: true})
+	require.NoError(t, err, "should insert the document")
+
+	return **res.New
+}
+
+func TestRevisionChecks(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	dbh := newStandInDatabase(t, newDocServer().handle)
+	item := insertStockItem(t, dbh)
+	res, err := UpdateIfMatch(ctx, dbh, "stock", item, map[string]int{"count": 5}, nil)
+	assert.NoError(err, "should update the unchanged document")
+	assert.Equal(item.Rev, res.Meta.OldRev, "should update the read revision")
+
+	_, err = UpdateIfMatch(ctx, dbh, "stock", item, map[string]int{"count": 6}, nil)
+	assert.ErrorIs(err, ErrRevisionConflict, "should not update the changed document")
+	var rerr *RevisionConflictError
+	assert.ErrorAs(err, &rerr, "should return a RevisionConflictError")
+	assert.Equal(item.Rev, rerr.Expected, "should report the expected revision")
+	assert.Equal(res.Meta.Rev, rerr.Current, "should report the current revision")
+	var aerr driver.ArangoError
+	assert.ErrorAs(err, &aerr, "should wrap the server error")
+
+	item.Name = "tip"
+	_, err = ReplaceIfMatch(ctx, dbh, "stock", item, nil)
+	assert.ErrorIs(err, ErrRevisionConflict, "should not replace the changed document")
+	_, err = RemoveIfMatch(ctx, dbh, "stock", item, nil)
+	assert.ErrorIs(err, ErrRevisionConflict, "should not remove the changed document")
+
+	current, err := Get[stockItem](ctx, dbh, "stock", "gel")
+	assert.NoError(err, "should get the document")
+	assert.Equal(5, current.Count, "should keep the first update")
+	current.Name = "tip"
+	rep, err := ReplaceIfMatch(ctx, dbh, "stock", &current, &DocOptions{ReturnNew: true})
+	assert.NoError(err, "should replace the current document")
+	assert.Equal("tip", (*rep.New).Name, "should return the replaced document")
+	_, err = RemoveIfMatch(ctx, dbh, "stock", *rep.New, nil)
+	assert.NoError(err, "should remove the current document")
+
+	_, err = ReplaceIfMatch(ctx, dbh, "stock", stockItem{Name: "box"}, nil)
+	assert.Error(err, "should return error for document without revision")
+	_, err = ReplaceIfMatch(ctx, dbh, "stock", map[string]string{"_key": "box"}, nil)
+	assert.Error(err, "should return error for document without DocumentMeta")
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	dbh := newStandInDatabase(t, newDocServer().handle)
+	insertStockItem(t, dbh)
+	calls := 0
+	res, err := UpdateWithRetry(ctx, dbh, "stock", "gel", func(item *stockItem) error {
+		calls++
+		if calls == 1 {
+			// concurrent edit between the read and the write
+			_, err := Update[stockItem](ctx, dbh, "stock", "gel", map[string]int{"count": 10}, nil)
+			assert.NoError(err, "should update the document concurrently")
+		}
+		item.Count++
+
+		return nil
+	}, &DocOptions{ReturnNew: true})
+	assert.NoError(err, "should update after the conflict")
+	assert.Equal(2, calls, "should mutate the document again after the conflict")
+	assert.Equal(11, res.New.Count, "should apply the mutation on the current document")
+
+	errMutate := errors.New("out of stock")
+	_, err = UpdateWithRetry(ctx, dbh, "stock", "gel", func(item *stockItem) error {
+		return errMutate
+	}, nil)
+	assert.ErrorIs(err, errMutate, "should return the error of the mutation")
+
+	calls = 0
+	_, err = UpdateWithRetry(ctx, dbh, "stock", "gel", func(item *stockItem) error {
+		calls++
+		_, err := Update[stockItem](ctx, dbh, "stock", "gel", map[string]int{"count": calls}, nil)
+		assert.NoError(err, "should update the document concurrently")
+
+		return nil
+	}, &DocOptions{MaxRetries: 2})
+	assert.ErrorIs(err, ErrRevisionConflict, "should give up after the retries")
+	assert.Equal(3, calls, "should run once and retry twice")
+	_, err = UpdateWithRetry(ctx, dbh, "stock", "box", func(item *stockItem) error {
+		return nil
+	}, nil)
+	assert.ErrorIs(err, ErrNotFound, "should return error for missing document")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"sync"
 
 	driver "github.com/arangodb/go-driver"
 	"github.com/arangodb/go-driver/http"
@@ -13,15 +14,64 @@ import (
 // Session is a connected database client.
 type Session struct {
 	client driver.Client
+	// running transactions begun through the session, nil if they are not
+	// tracked
+	tracker *txTracker
+	// reject missing and unused bind parameters before running queries
+	checkBindVars bool
+	// first time the transactions of every database were begun or listed,
+	// shared by the Database values of the session
+	seenMu sync.Mutex
+	seen   map[string]*txFirstSeen
+}
+
+// SessionOption configures a Session.
+type SessionOption func(*Session)
+
+// WithTransactionTracking keeps track of the stream transactions begun
+// through the databases of the session, Close aborts the ones that are
+// still running.
+func WithTransactionTracking() SessionOption {
+	return func(s *Session) {
+		s.tracker = newTxTracker()
+	}
+}
+
+// WithBindVarChecks checks the bind parameters of the queries run through
+// the databases of the session, missing and unused bind parameters are then
+// rejected before a query runs. It costs Do and Exec a parse request on the
+// server that they would not make otherwise.
+func WithBindVarChecks() SessionOption {
+	return func(s *Session) {
+		s.checkBindVars = true
+	}
 }
 
 // NewSessionFromClient creates a new Session from an existing client
 //
 //	 You could also do this
-//	    &Session{client}
+//	    &Session{client: client}
 //	Funny isn't it
-func NewSessionFromClient(client driver.Client) *Session {
-	return &Session{client}
+func NewSessionFromClient(
+	client driver.Client,
+	opts ...SessionOption,
+) *Session {
+	return newSession(client, opts...)
+}
+
+func newSession(client driver.Client, opts ...SessionOption) *Session {
+	sess := &Session{client: client}
+	for _, opt := range opts {
+		opt(sess)
+	}
+
+	return sess
+}
+
+// Close aborts the tracked transactions that are still running, it does
+// nothing unless the session was created WithTransactionTracking.
+func (s *Session) Close() error {
+	return s.tracker.abortAll()
 }
 
 // Connect is a constructor for new client.
@@ -29,6 +79,7 @@ func Connect(
 	host, user, password string,
 	port int,
 	istls bool,
+	opts ...SessionOption,
 ) (*Session, error) {
 	connConf := http.ConnectionConfig{
 		Endpoints: []string{
@@ -57,7 +108,7 @@ func Connect(
 		return &Session{}, fmt.Errorf("could not get a client instance %s", err)
 	}
 
-	return &Session{client}, nil
+	return newSession(client, opts...), nil
 }
 
 // NewSessionDb connects to arangodb and returns a new session
@@ -69,12 +120,20 @@ func NewSessionDb(connP *ConnectParams) (*Session, *Database, error) {
 	if err := validate.Struct(connP); err != nil {
 		return sess, dbr, fmt.Errorf("error in validation %s", err)
 	}
+	var opts []SessionOption
+	if connP.TrackTransactions {
+		opts = append(opts, WithTransactionTracking())
+	}
+	if connP.CheckBindVars {
+		opts = append(opts, WithBindVarChecks())
+	}
 	sess, err := Connect(
 		connP.Host,
 		connP.User,
 		connP.Pass,
 		connP.Port,
 		connP.Istls,
+		opts...,
 	)
 	if err != nil {
 		return sess, dbr, err
@@ -206,5 +265,26 @@ func (s *Session) getDatabase(name string) (*Database, error) {
 		)
 	}
 
-	return &Database{dbh}, nil
+	return &Database{
+		dbh:           dbh,
+		conn:          s.client.Connection(),
+		seen:          s.firstSeen(name),
+		tracker:       s.tracker,
+		checkBindVars: s.checkBindVars,
+	}, nil
+}
+
+// firstSeen returns the first seen times of the transactions of the
+// database, so that every Database of the session measures their age alike.
+func (s *Session) firstSeen(name string) *txFirstSeen {
+	s.seenMu.Lock()
+	defer s.seenMu.Unlock()
+	if s.seen == nil {
+		s.seen = make(map[string]*txFirstSeen)
+	}
+	if _, ok := s.seen[name]; !ok {
+		s.seen[name] = newTxFirstSeen()
+	}
+
+	return s.seen[name]
 }
@@ -373,17 +373,17 @@ func beginTestTransaction(params TxParams) *TransactionHandler {
 	return tx
 }
 
-// assertTxCanceled checks if a transaction is canceled as expected
-func assertTxCanceled(
+// assertTxState checks if a transaction is in the expected state
+func assertTxState(
 	t *testing.T,
 	tx *TransactionHandler,
-	expectedCanceled bool,
+	expected TransactionState,
 ) {
 	t.Helper()
 	assert := require.New(t)
-	assert.Equal(expectedCanceled, tx.canceled,
-		"Transaction canceled state mismatch, expected: %v, got: %v",
-		expectedCanceled, tx.canceled)
+	assert.Equal(expected, tx.State(),
+		"Transaction state mismatch, expected: %s, got: %s",
+		expected, tx.State())
 }
 
 // insertTestDocument inserts a test document using the provided transaction
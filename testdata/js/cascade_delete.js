@@ -0,0 +1,9 @@
+function(params) {
+	const db = require('@arangodb').db
+	const removed = db._query(
+		'FOR o IN order FILTER o.user == @user REMOVE o IN order RETURN 1',
+		{ user: params.user }
+	).toArray().length
+	db._collection('users').remove(params.user)
+	return { removed: removed }
+}
@@ -0,0 +1,4 @@
+function(params) {
+	const db = require('@arangodb').db
+	return db._collection(params.collection).count()
+}
@@ -2,17 +2,98 @@ package arangomanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	driver "github.com/arangodb/go-driver"
 )
 
-// TransactionHandler represents a transaction with begin/commit/abort capabilities
+// TransactionState is the state of a stream transaction in its lifecycle.
+type TransactionState int
+
+const (
+	// TxRunning is the state of a transaction that was begun and is not
+	// finished yet
+	TxRunning TransactionState = iota
+	// TxCommitted is the state of a committed transaction
+	TxCommitted
+	// TxAborted is the state of an aborted transaction, including the ones
+	// aborted by the idle timeout
+	TxAborted
+	// TxFailed is the state of a transaction that could not be committed
+	// or aborted, its outcome on the server is unknown
+	TxFailed
+)
+
+func (s TransactionState) String() string {
+	switch s {
+	case TxRunning:
+		return "running"
+	case TxCommitted:
+		return "committed"
+	case TxAborted:
+		return "aborted"
+	case TxFailed:
+		return "failed"
+	}
+
+	return fmt.Sprintf("TransactionState(%d)", int(s))
+}
+
+// ErrTransactionFinished is matched by the errors of operations on a
+// transaction that is no longer running.
+var ErrTransactionFinished = errors.New("transaction is finished")
+
+// TransactionStateError is returned for operations on a transaction that
+// is no longer running.
+type TransactionStateError struct {
+	ID    driver.TransactionID
+	State TransactionState
+	// IdleTimeout is set if the transaction was aborted by the idle timeout
+	IdleTimeout time.Duration
+}
+
+func (e *TransactionStateError) Error() string {
+	if e.IdleTimeout > 0 {
+		return fmt.Sprintf(
+			"transaction %s aborted after being idle for %s",
+			e.ID, e.IdleTimeout,
+		)
+	}
+
+	return fmt.Sprintf("transaction %s is %s", e.ID, e.State)
+}
+
+// Is matches ErrTransactionFinished.
+func (e *TransactionStateError) Is(target error) bool {
+	return target == ErrTransactionFinished
+}
+
+// TransactionHandler represents a transaction with begin/commit/abort
+// capabilities. It is safe for concurrent use, only one of the concurrent
+// calls of Commit and Abort finishes the transaction.
 type TransactionHandler struct {
-	db       *Database
-	id       driver.TransactionID
-	ctx      context.Context
-	canceled bool
+	db  *Database
+	id  driver.TransactionID
+	ctx context.Context
+	// context given to BeginTransaction, without the transaction ID
+	parent context.Context
+	// idle timeout of the transaction, disabled if zero
+	idleTimeout time.Duration
+	idleTimer   *time.Timer
+	// number of running operations, the idle timer is stopped while it is
+	// not zero
+	inFlight int
+	// time the last operation finished
+	lastActive time.Time
+	// guards the state, the idle flag, the idle timer, inFlight and
+	// lastActive
+	mu    sync.Mutex
+	state TransactionState
+	// set when the transaction was aborted by the idle timer
+	idle bool
 }
 
 // Context returns the transaction context which should be used for all operations within the transaction
@@ -25,37 +106,70 @@ func (t *TransactionHandler) ID() driver.TransactionID {
 	return t.id
 }
 
-// Commit commits the transaction
+// State returns the current state of the transaction.
+func (t *TransactionHandler) State() TransactionState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state
+}
+
+// Commit commits the transaction. If the commit fails the transaction is
+// aborted on a best-effort basis and ends up in the TxFailed state.
 func (t *TransactionHandler) Commit() error {
-	if t.canceled {
-		return fmt.Errorf("cannot commit a canceled transaction")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.stateError(); err != nil {
+		return err
 	}
+	// the transaction is no longer running whatever the outcome
+	defer t.db.tracker.remove(t)
+	t.stopIdleTimer()
+	if err := t.db.dbh.CommitTransaction(t.parent, t.id, nil); err != nil {
+		t.state = TxFailed
+		// the caller context might be canceled, the abort is attempted
+		// regardless
+		aerr := t.db.dbh.AbortTransaction(
+			context.WithoutCancel(t.parent), t.id, nil,
+		)
+		if aerr != nil {
+			return fmt.Errorf(
+				"failed to commit transaction: %w, failed to abort: %s",
+				err, aerr,
+			)
+		}
 
-	if err := t.db.dbh.CommitTransaction(context.Background(), t.id, nil); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	t.state = TxCommitted
 
-	t.canceled = true
 	return nil
 }
 
-// Abort aborts the transaction
+// Abort aborts the transaction, a failed abort leaves the transaction in
+// the TxFailed state.
 func (t *TransactionHandler) Abort() error {
-	if t.canceled {
-		return fmt.Errorf("transaction already canceled")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.stateError(); err != nil {
+		return err
 	}
+	// the transaction is no longer running whatever the outcome
+	defer t.db.tracker.remove(t)
+	t.stopIdleTimer()
+	if err := t.db.dbh.AbortTransaction(t.parent, t.id, nil); err != nil {
+		t.state = TxFailed
 
-	if err := t.db.dbh.AbortTransaction(context.Background(), t.id, nil); err != nil {
 		return fmt.Errorf("failed to abort transaction: %w", err)
 	}
+	t.state = TxAborted
 
-	t.canceled = true
 	return nil
 }
 
 // Status retrieves the current status of the transaction
 func (t *TransactionHandler) Status() (driver.TransactionStatusRecord, error) {
-	status, err := t.db.dbh.TransactionStatus(context.Background(), t.id)
+	status, err := t.db.dbh.TransactionStatus(t.parent, t.id)
 	if err != nil {
 		return driver.TransactionStatusRecord{}, fmt.Errorf(
 			"failed to get transaction status: %w",
@@ -71,6 +185,13 @@ func (t *TransactionHandler) Do(
 	query string,
 	bindVars map[string]interface{},
 ) error {
+	if err := t.pauseIdleTimer(); err != nil {
+		return err
+	}
+	defer t.resumeIdleTimer()
+	if err := t.db.validateQuery(t.parent, query, bindVars); err != nil {
+		return err
+	}
 	ctx := driver.WithSilent(t.ctx)
 	_, err := t.db.dbh.Query(ctx, query, bindVars)
 	if err != nil {
@@ -86,14 +207,290 @@ func (t *TransactionHandler) DoRun(
 	query string,
 	bindVars map[string]interface{},
 ) (*Result, error) {
-	if err := t.db.dbh.ValidateQuery(t.ctx, query); err != nil {
-		return &Result{
+	if err := t.pauseIdleTimer(); err != nil {
+		return &Result{empty: true}, err
+	}
+	defer t.resumeIdleTimer()
+	if err := t.db.validateQuery(t.parent, query, bindVars); err != nil {
+		return &Result{empty: true}, err
+	}
+	cqr, err := t.db.dbh.Query(t.ctx, query, bindVars)
+
+	return t.db.getResult(cqr, err)
+}
+
+// SearchRows query the database with bind parameters within the
+// transaction that is expected to return multiple rows of result.
+func (t *TransactionHandler) SearchRows(
+	query string,
+	bindVars map[string]interface{},
+) (*Resultset, error) {
+	if err := t.pauseIdleTimer(); err != nil {
+		return &Resultset{empty: true}, err
+	}
+	defer t.resumeIdleTimer()
+	if err := t.db.validateQuery(t.parent, query, bindVars); err != nil {
+		return &Resultset{empty: true}, err
+	}
+	cqr, err := t.db.dbh.Query(t.ctx, query, bindVars)
+	if err != nil {
+		return &Resultset{
 				empty: true,
 			}, fmt.Errorf(
-				"error in validating the query %s",
+				"error in running search %w",
 				err,
 			)
 	}
+	if !cqr.HasMore() {
+		return &Resultset{empty: true}, nil
+	}
+
+	return &Resultset{cursor: cqr, ctx: t.ctx}, nil
+}
+
+// CountWithParams query the database with bind parameters within the
+// transaction that is expected to return count of result.
+func (t *TransactionHandler) CountWithParams(
+	query string,
+	bindVars map[string]interface{},
+) (int64, error) {
+	if err := t.pauseIdleTimer(); err != nil {
+		return 0, err
+	}
+	defer t.resumeIdleTimer()
+	if err := t.db.validateQuery(t.parent, query, bindVars); err != nil {
+		return 0, err
+	}
+	cobj, err := t.db.dbh.Query(
+		driver.WithQueryCount(t.ctx, true),
+		query,
+		bindVars,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error with query %w", err)
+	}
+
+	return cobj.Count(), nil
+}
+
+// GetRow query the database with bind parameters within the transaction
+// that is expected to return single row of result.
+func (t *TransactionHandler) GetRow(
+	query string,
+	bindVars map[string]interface{},
+) (*Result, error) {
+	if err := t.pauseIdleTimer(); err != nil {
+		return &Result{empty: true}, err
+	}
+	defer t.resumeIdleTimer()
+	if err := t.db.validateQuery(t.parent, query, bindVars); err != nil {
+		return &Result{empty: true}, err
+	}
 	cqr, err := t.db.dbh.Query(t.ctx, query, bindVars)
+
 	return t.db.getResult(cqr, err)
 }
+
+// CreateDocument creates a document in the collection within the
+// transaction.
+func (t *TransactionHandler) CreateDocument(
+	collection string,
+	document interface{},
+) (driver.DocumentMeta, error) {
+	var meta driver.DocumentMeta
+	err := t.withCollection(collection, func(coll driver.Collection) error {
+		var err error
+		meta, err = coll.CreateDocument(t.ctx, document)
+
+		return err
+	})
+	if err != nil {
+		return meta, fmt.Errorf("error in creating document %w", err)
+	}
+
+	return meta, nil
+}
+
+// ReadDocument reads the document with the given key from the collection
+// within the transaction into result.
+func (t *TransactionHandler) ReadDocument(
+	collection, key string,
+	result interface{},
+) (driver.DocumentMeta, error) {
+	var meta driver.DocumentMeta
+	err := t.withCollection(collection, func(coll driver.Collection) error {
+		var err error
+		meta, err = coll.ReadDocument(t.ctx, key, result)
+
+		return err
+	})
+	if err != nil {
+		return meta, fmt.Errorf("error in reading document %w", err)
+	}
+
+	return meta, nil
+}
+
+// UpdateDocument partially updates the document with the given key within
+// the transaction.
+func (t *TransactionHandler) UpdateDocument(
+	collection, key string,
+	update interface{},
+) (driver.DocumentMeta, error) {
+	var meta driver.DocumentMeta
+	err := t.withCollection(collection, func(coll driver.Collection) error {
+		var err error
+		meta, err = coll.UpdateDocument(t.ctx, key, update)
+
+		return err
+	})
+	if err != nil {
+		return meta, fmt.Errorf("error in updating document %w", err)
+	}
+
+	return meta, nil
+}
+
+// ReplaceDocument replaces the document with the given key within the
+// transaction.
+func (t *TransactionHandler) ReplaceDocument(
+	collection, key string,
+	document interface{},
+) (driver.DocumentMeta, error) {
+	var meta driver.DocumentMeta
+	err := t.withCollection(collection, func(coll driver.Collection) error {
+		var err error
+		meta, err = coll.ReplaceDocument(t.ctx, key, document)
+
+		return err
+	})
+	if err != nil {
+		return meta, fmt.Errorf("error in replacing document %w", err)
+	}
+
+	return meta, nil
+}
+
+// RemoveDocument removes the document with the given key within the
+// transaction.
+func (t *TransactionHandler) RemoveDocument(
+	collection, key string,
+) (driver.DocumentMeta, error) {
+	var meta driver.DocumentMeta
+	err := t.withCollection(collection, func(coll driver.Collection) error {
+		var err error
+		meta, err = coll.RemoveDocument(t.ctx, key)
+
+		return err
+	})
+	if err != nil {
+		return meta, fmt.Errorf("error in removing document %w", err)
+	}
+
+	return meta, nil
+}
+
+// withCollection runs fn with the collection while the idle timer is
+// paused.
+func (t *TransactionHandler) withCollection(
+	name string,
+	fn func(coll driver.Collection) error,
+) error {
+	if err := t.pauseIdleTimer(); err != nil {
+		return err
+	}
+	defer t.resumeIdleTimer()
+	coll, err := t.db.dbh.Collection(t.parent, name)
+	if err != nil {
+		return fmt.Errorf("error in getting collection %s %w", name, err)
+	}
+
+	return fn(coll)
+}
+
+// finished checks if the transaction is no longer running.
+func (t *TransactionHandler) finished() bool {
+	return t.State() != TxRunning
+}
+
+func (t *TransactionHandler) startIdleTimer() {
+	if t.idleTimeout <= 0 {
+		return
+	}
+	t.idleTimer = time.AfterFunc(t.idleTimeout, t.abortIdle)
+}
+
+// abortIdle aborts the transaction once the idle timeout expired. The
+// timer might fire while an operation is starting, so the transaction is
+// only aborted if no operation ran during the timeout. The state is
+// changed under the lock, while the abort request is sent after releasing
+// it.
+func (t *TransactionHandler) abortIdle() {
+	t.mu.Lock()
+	if t.state != TxRunning || t.inFlight > 0 ||
+		time.Since(t.lastActive) < t.idleTimeout {
+		t.mu.Unlock()
+
+		return
+	}
+	t.idle = true
+	t.state = TxAborted
+	t.mu.Unlock()
+	defer t.db.tracker.remove(t)
+	if err := t.db.dbh.AbortTransaction(
+		context.WithoutCancel(t.parent), t.id, nil,
+	); err != nil {
+		t.mu.Lock()
+		t.state = TxFailed
+		t.mu.Unlock()
+	}
+}
+
+// pauseIdleTimer stops the idle timer while an operation is running, it
+// returns an error if the transaction is no longer running. Every
+// successful call has to be followed by a call of resumeIdleTimer.
+func (t *TransactionHandler) pauseIdleTimer() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.stateError(); err != nil {
+		return err
+	}
+	t.inFlight++
+	if t.inFlight == 1 {
+		t.stopIdleTimer()
+	}
+
+	return nil
+}
+
+// resumeIdleTimer restarts the idle timer once the last of the overlapping
+// operations is finished.
+func (t *TransactionHandler) resumeIdleTimer() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight--
+	t.lastActive = time.Now()
+	if t.inFlight == 0 && t.idleTimer != nil && t.state == TxRunning {
+		t.idleTimer.Reset(t.idleTimeout)
+	}
+}
+
+func (t *TransactionHandler) stopIdleTimer() {
+	if t.idleTimer != nil {
+		t.idleTimer.Stop()
+	}
+}
+
+// stateError returns a TransactionStateError if the transaction is no
+// longer running, the caller must hold the lock.
+func (t *TransactionHandler) stateError() error {
+	if t.state == TxRunning {
+		return nil
+	}
+	serr := &TransactionStateError{ID: t.id, State: t.state}
+	if t.idle {
+		serr.IdleTimeout = t.idleTimeout
+	}
+
+	return serr
+}
@@ -3,7 +3,9 @@ package arangomanager
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -67,17 +69,18 @@ func TestTransactionLifecycle(t *testing.T) {
 			ReadOnly: false,
 		})
 		assert.NotNil(tx)
-		assertTxCanceled(t, tx, false)
+		assertTxState(t, tx, TxRunning)
 
 		// Commit the transaction
 		err := tx.Commit()
 		assert.NoError(err)
-		assertTxCanceled(t, tx, true)
+		assertTxState(t, tx, TxCommitted)
 
 		// Try to commit again, should fail
 		err = tx.Commit()
 		assert.Error(err)
-		assert.Contains(err.Error(), "cannot commit a canceled transaction")
+		assert.ErrorIs(err, ErrTransactionFinished)
+		assert.Contains(err.Error(), "is committed")
 	})
 
 	t.Run("Abort", func(t *testing.T) {
@@ -90,17 +93,18 @@ func TestTransactionLifecycle(t *testing.T) {
 			ReadOnly: false,
 		})
 		assert.NotNil(tx)
-		assertTxCanceled(t, tx, false)
+		assertTxState(t, tx, TxRunning)
 
 		// Abort the transaction
 		err := tx.Abort()
 		assert.NoError(err)
-		assertTxCanceled(t, tx, true)
+		assertTxState(t, tx, TxAborted)
 
 		// Try to abort again, should fail
 		err = tx.Abort()
 		assert.Error(err)
-		assert.Contains(err.Error(), "transaction already canceled")
+		assert.ErrorIs(err, ErrTransactionFinished)
+		assert.Contains(err.Error(), "is aborted")
 	})
 }
 
@@ -258,7 +262,7 @@ func TestTransactionIsolation(t *testing.T) {
 	// Commit the transaction
 	err := tx.Commit()
 	assert.NoError(err)
-	assertTxCanceled(t, tx, true)
+	assertTxState(t, tx, TxCommitted)
 
 	// Check document is now visible
 	assertDocumentExists(DocExistsParams{
@@ -270,3 +274,297 @@ func TestTransactionIsolation(t *testing.T) {
 		ShouldExist: true,
 	})
 }
+
+func TestBeginTransactionOptions(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), &TransactionOptions{
+		ReadCollections:      []string{"users"},
+		WriteCollections:     []string{"orders"},
+		ExclusiveCollections: []string{"stock"},
+		WaitForSync:          true,
+		AllowImplicit:        true,
+		LockTimeout:          5,
+		MaxTransactionSize:   1024,
+	})
+	assert.NoError(err, "should begin the transaction")
+	assert.NoError(tx.Abort(), "should abort the transaction")
+	assert.Len(srv.begins, 1, "should send a single begin request")
+	assert.Equal(
+		map[string]interface{}{
+			"waitForSync":        true,
+			"allowImplicit":      true,
+			"lockTimeout":        float64(5),
+			"maxTransactionSize": float64(1024),
+			"collections": map[string]interface{}{
+				"read":      []interface{}{"users"},
+				"write":     []interface{}{"orders"},
+				"exclusive": []interface{}{"stock"},
+			},
+		},
+		srv.begins[0],
+		"should send all the options",
+	)
+	_, err = dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction with default options")
+	assert.Equal(
+		float64(DefaultTransactionOptions().MaxTransactionSize),
+		srv.begins[1]["maxTransactionSize"],
+		"should send the default transaction size",
+	)
+}
+
+func TestBeginTransactionInvalidOptions(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	for name, opts := range map[string]*TransactionOptions{
+		"negative lock timeout":  {LockTimeout: -1},
+		"negative size":          {MaxTransactionSize: -1},
+		"negative idle timeout":  {IdleTimeout: -time.Second},
+		"negative retries":       {MaxRetries: -1},
+		"read and exclusive":     {ReadCollections: []string{"stock"}, ExclusiveCollections: []string{"stock"}},
+		"write and exclusive":    {WriteCollections: []string{"stock"}, ExclusiveCollections: []string{"stock"}},
+		"negative retry backoff": {RetryBackoff: -time.Second},
+	} {
+		_, err := dbh.BeginTransaction(context.Background(), opts)
+		assert.Errorf(err, "should return error for %s", name)
+	}
+	assert.Zero(srv.begun, "should not send invalid options")
+}
+
+func TestTransactionIdleTimeout(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), &TransactionOptions{
+		WriteCollections: []string{"stock"},
+		IdleTimeout:      50 * time.Millisecond,
+	})
+	assert.NoError(err, "should begin the transaction")
+	for range 3 {
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(tx.Do("INSERT {} INTO stock", nil), "should keep the transaction alive")
+	}
+	assert.Eventually(tx.finished, time.Second, 10*time.Millisecond, "should abort the idle transaction")
+	assert.ErrorContains(
+		tx.Do("INSERT {} INTO stock", nil),
+		"idle",
+		"should reject operations after the idle timeout",
+	)
+	assert.Error(tx.Commit(), "should not commit after the idle timeout")
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal([]string{"1"}, srv.aborted, "should abort the transaction on the server")
+}
+
+func TestTransactionIdleTimeoutOverlap(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), &TransactionOptions{
+		WriteCollections: []string{"stock"},
+		IdleTimeout:      30 * time.Millisecond,
+	})
+	assert.NoError(err, "should begin the transaction")
+	assert.NoError(tx.pauseIdleTimer(), "should start the long operation")
+	assert.NoError(tx.Do("INSERT {} INTO stock", nil), "should run the short operation")
+	time.Sleep(90 * time.Millisecond)
+	assert.False(tx.finished(), "should not abort while an operation is running")
+	tx.resumeIdleTimer()
+	assert.Eventually(tx.finished, time.Second, 10*time.Millisecond, "should abort once every operation is finished")
+}
+
+func TestTransactionIdleTimerFired(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), &TransactionOptions{
+		WriteCollections: []string{"stock"},
+		IdleTimeout:      time.Hour,
+	})
+	assert.NoError(err, "should begin the transaction")
+	assert.NoError(tx.pauseIdleTimer(), "should start the operation")
+	// the timer fired right before the operation started
+	tx.abortIdle()
+	assert.Equal(TxRunning, tx.State(), "should not abort while an operation is running")
+	tx.resumeIdleTimer()
+	tx.abortIdle()
+	assert.Equal(TxRunning, tx.State(), "should not abort right after an operation")
+	tx.mu.Lock()
+	tx.lastActive = time.Now().Add(-2 * time.Hour)
+	tx.mu.Unlock()
+	tx.abortIdle()
+	assert.Equal(TxAborted, tx.State(), "should abort after the idle timeout")
+	assert.ErrorContains(tx.Do("INSERT {} INTO stock", nil), "idle", "should reject operations")
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal([]string{"1"}, srv.aborted, "should abort the transaction on the server")
+}
+
+func TestTransactionQueries(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{rows: []interface{}{
+		map[string]interface{}{"name": "gel"},
+		map[string]interface{}{"name": "tip"},
+	}}
+	dbh, err := newStandInSession(t, srv.handle, WithBindVarChecks()).DB(standInDB)
+	assert.NoError(err, "should get the database")
+	tx, err := dbh.BeginTransaction(context.Background(), &TransactionOptions{
+		ReadCollections: []string{"stock"},
+	})
+	assert.NoError(err, "should begin the transaction")
+	query := "FOR s IN stock FILTER s.name != @name RETURN s"
+	bindVars := map[string]interface{}{"name": "box"}
+	rs, err := tx.SearchRows(query, bindVars)
+	assert.NoError(err, "should search within the transaction")
+	names := make([]string, 0)
+	for rs.Scan() {
+		var row map[string]interface{}
+		assert.NoError(rs.Read(&row), "should read the row")
+		names = append(names, row["name"].(string))
+	}
+	assert.Equal([]string{"gel", "tip"}, names, "should read all the rows")
+	count, err := tx.CountWithParams(query, bindVars)
+	assert.NoError(err, "should count within the transaction")
+	assert.Equal(int64(2), count, "should match the count")
+	res, err := tx.GetRow(query, bindVars)
+	assert.NoError(err, "should get the row within the transaction")
+	assert.False(res.IsEmpty(), "should have a row")
+	_, err = tx.GetRow(query, nil)
+	assert.ErrorContains(err, "missing bind parameters", "should check the bind parameters")
+	assert.ErrorContains(
+		tx.Do("INSERT { name: @name } INTO stock", nil),
+		"missing bind parameters",
+		"should check the bind parameters of data modification queries",
+	)
+	assert.NoError(tx.Commit(), "should commit the transaction")
+	assert.Equal(
+		[]string{"POST cursor 1", "POST cursor 1", "POST cursor 1"},
+		srv.requests,
+		"should run all the queries within the transaction",
+	)
+}
+
+func TestTransactionDocuments(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), &TransactionOptions{
+		WriteCollections: []string{"stock"},
+	})
+	assert.NoError(err, "should begin the transaction")
+	meta, err := tx.CreateDocument("stock", map[string]string{"name": "gel"})
+	assert.NoError(err, "should create the document")
+	assert.Equal("1", meta.Key, "should return the key of the document")
+	var doc map[string]interface{}
+	_, err = tx.ReadDocument("stock", "1", &doc)
+	assert.NoError(err, "should read the document")
+	assert.Equal("gel", doc["name"], "should match the document")
+	_, err = tx.UpdateDocument("stock", "1", map[string]string{"name": "tip"})
+	assert.NoError(err, "should update the document")
+	_, err = tx.ReplaceDocument("stock", "1", map[string]string{"name": "box"})
+	assert.NoError(err, "should replace the document")
+	_, err = tx.RemoveDocument("stock", "1")
+	assert.NoError(err, "should remove the document")
+	assert.NoError(tx.Abort(), "should abort the transaction")
+	assert.Equal(
+		[]string{
+			"POST document/stock 1",
+			"GET document/stock/1 1",
+			"PATCH document/stock/1 1",
+			"PUT document/stock/1 1",
+			"DELETE document/stock/1 1",
+		},
+		srv.requests,
+		"should run all the document operations within the transaction",
+	)
+}
+
+func TestTransactionCallerContext(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := dbh.BeginTransaction(ctx, nil)
+	assert.NoError(err, "should begin the transaction")
+	cancel()
+	assert.ErrorIs(tx.Commit(), context.Canceled, "should commit with the caller context")
+	_, err = tx.Status()
+	assert.ErrorIs(err, context.Canceled, "should get the status with the caller context")
+	assert.Empty(srv.committed, "should not commit with a canceled context")
+	assert.Equal(TxFailed, tx.State(), "should fail the transaction")
+	assert.Equal([]string{"1"}, srv.aborted, "should abort after the failed commit")
+}
+
+func TestTransactionState(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	assert.Equal(TxRunning, tx.State(), "should be running")
+	assert.NoError(tx.Commit(), "should commit the transaction")
+	assert.Equal(TxCommitted, tx.State(), "should be committed")
+	for name, err := range map[string]error{
+		"commit": tx.Commit(),
+		"abort":  tx.Abort(),
+		"do":     tx.Do("INSERT {} INTO stock", nil),
+	} {
+		assert.ErrorIsf(err, ErrTransactionFinished, "should not %s a committed transaction", name)
+		var serr *TransactionStateError
+		assert.ErrorAsf(err, &serr, "should return state error for %s", name)
+		assert.Equal(TxCommitted, serr.State, "should report the committed state")
+	}
+	_, err = tx.SearchRows("FOR s IN stock RETURN s", nil)
+	assert.ErrorIs(err, ErrTransactionFinished, "should not search in a committed transaction")
+	assert.Equal("failed", TxFailed.String(), "should match the name of the state")
+}
+
+func TestTransactionConcurrentCommit(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for idx := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if idx%2 == 0 {
+				errs <- tx.Commit()
+				return
+			}
+			errs <- tx.Abort()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	finished := 0
+	for err := range errs {
+		if err == nil {
+			finished++
+			continue
+		}
+		assert.ErrorIs(err, ErrTransactionFinished, "should reject the other calls")
+	}
+	assert.Equal(1, finished, "should finish the transaction once")
+	assert.Equal(
+		1,
+		len(srv.committed)+len(srv.aborted),
+		"should send a single request to finish the transaction",
+	)
+}
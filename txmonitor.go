@@ -0,0 +1,227 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+// TransactionInfo describes a stream transaction known to the server.
+type TransactionInfo struct {
+	ID    driver.TransactionID     `json:"id"`
+	State driver.TransactionStatus `json:"state"`
+}
+
+// ListTransactions returns the stream transactions of the database that
+// are known to the server, including the ones begun by other processes.
+func (d *Database) ListTransactions(
+	ctx context.Context,
+) ([]*TransactionInfo, error) {
+	if d.conn == nil {
+		return nil, ErrNoConnection
+	}
+	req, err := d.conn.NewRequest(
+		"GET",
+		path.Join("_db", url.PathEscape(d.dbh.Name()), "_api/transaction"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating transaction list request %s", err)
+	}
+	resp, err := d.conn.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error in listing transactions %w", err)
+	}
+	if err := resp.CheckStatus(200); err != nil {
+		return nil, fmt.Errorf("error in listing transactions %w", err)
+	}
+	var result struct {
+		Transactions []*TransactionInfo `json:"transactions"`
+	}
+	if err := resp.ParseBody("", &result); err != nil {
+		return nil, fmt.Errorf("error in decoding transaction list %s", err)
+	}
+	d.seen.update(result.Transactions)
+
+	return result.Transactions, nil
+}
+
+// StaleTransactions returns the running stream transactions that are older
+// than olderThan.
+//
+// The server does not report when a transaction began, so the age of a
+// transaction begun by another process is counted from the first time it
+// was listed through a Database of the same Session. The first call of a
+// process therefore finds none of them stale, a process that cleans up
+// after crashed workers has to keep its Session and call it periodically,
+// at intervals shorter than olderThan.
+func (d *Database) StaleTransactions(
+	ctx context.Context,
+	olderThan time.Duration,
+) ([]*TransactionInfo, error) {
+	txs, err := d.ListTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stale := make([]*TransactionInfo, 0)
+	for _, trx := range txs {
+		if trx.State == driver.TransactionRunning &&
+			d.seen.age(trx.ID) >= olderThan {
+			stale = append(stale, trx)
+		}
+	}
+
+	return stale, nil
+}
+
+// AbortStaleTransactions aborts the transactions returned by
+// StaleTransactions and returns their IDs, the same polling requirement
+// applies.
+func (d *Database) AbortStaleTransactions(
+	ctx context.Context,
+	olderThan time.Duration,
+) ([]driver.TransactionID, error) {
+	txs, err := d.StaleTransactions(ctx, olderThan)
+	if err != nil {
+		return nil, err
+	}
+	aborted := make([]driver.TransactionID, 0)
+	var errs []error
+	for _, trx := range txs {
+		if err := d.dbh.AbortTransaction(ctx, trx.ID, nil); err != nil {
+			errs = append(
+				errs,
+				fmt.Errorf("error in aborting transaction %s %w", trx.ID, err),
+			)
+
+			continue
+		}
+		aborted = append(aborted, trx.ID)
+	}
+
+	return aborted, errors.Join(errs...)
+}
+
+// txFirstSeen keeps the time a transaction was begun or first listed.
+type txFirstSeen struct {
+	mu    sync.Mutex
+	times map[driver.TransactionID]time.Time
+}
+
+func newTxFirstSeen() *txFirstSeen {
+	return &txFirstSeen{times: make(map[driver.TransactionID]time.Time)}
+}
+
+func (fs *txFirstSeen) mark(id driver.TransactionID) {
+	if fs == nil {
+		return
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.times[id]; !ok {
+		fs.times[id] = time.Now()
+	}
+}
+
+// update marks the running transactions and forgets the ones that are no
+// longer running.
+func (fs *txFirstSeen) update(txs []*TransactionInfo) {
+	if fs == nil {
+		return
+	}
+	now := time.Now()
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	running := make(map[driver.TransactionID]bool)
+	for _, trx := range txs {
+		if trx.State != driver.TransactionRunning {
+			continue
+		}
+		running[trx.ID] = true
+		if _, ok := fs.times[trx.ID]; !ok {
+			fs.times[trx.ID] = now
+		}
+	}
+	for id := range fs.times {
+		if !running[id] {
+			delete(fs.times, id)
+		}
+	}
+}
+
+func (fs *txFirstSeen) age(id driver.TransactionID) time.Duration {
+	if fs == nil {
+		return 0
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	seen, ok := fs.times[id]
+	if !ok {
+		return 0
+	}
+
+	return time.Since(seen)
+}
+
+// txTracker keeps the running transactions begun through the databases of
+// a Session.
+type txTracker struct {
+	mu  sync.Mutex
+	txs map[*TransactionHandler]struct{}
+}
+
+func newTxTracker() *txTracker {
+	return &txTracker{txs: make(map[*TransactionHandler]struct{})}
+}
+
+func (tt *txTracker) add(trx *TransactionHandler) {
+	if tt == nil {
+		return
+	}
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.txs[trx] = struct{}{}
+}
+
+func (tt *txTracker) remove(trx *TransactionHandler) {
+	if tt == nil {
+		return
+	}
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	delete(tt.txs, trx)
+}
+
+func (tt *txTracker) running() []*TransactionHandler {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	txs := make([]*TransactionHandler, 0, len(tt.txs))
+	for trx := range tt.txs {
+		txs = append(txs, trx)
+	}
+
+	return txs
+}
+
+// abortAll aborts the tracked transactions that are still running.
+func (tt *txTracker) abortAll() error {
+	if tt == nil {
+		return nil
+	}
+	var errs []error
+	for _, trx := range tt.running() {
+		err := trx.Abort()
+		if err != nil && !errors.Is(err, ErrTransactionFinished) {
+			errs = append(errs, fmt.Errorf(
+				"error in aborting transaction %s %w", trx.ID(), err,
+			))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,110 @@
+package arangomanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTransactions(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{external: []string{"99"}}
+	dbh := newStandInDatabase(t, srv.handle)
+	tx, err := dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	assert.NoError(tx.Commit(), "should commit the transaction")
+	_, err = dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	txs, err := dbh.ListTransactions(context.Background())
+	assert.NoError(err, "should list the transactions")
+	assert.Equal(
+		[]*TransactionInfo{
+			{ID: "99", State: driver.TransactionRunning},
+			{ID: "1", State: driver.TransactionCommitted},
+			{ID: "2", State: driver.TransactionRunning},
+		},
+		txs,
+		"should match the transactions",
+	)
+	_, err = (&Database{dbh: dbh.dbh}).ListTransactions(context.Background())
+	assert.ErrorIs(err, ErrNoConnection, "should require the client connection")
+}
+
+func TestAbortStaleTransactions(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{external: []string{"99"}}
+	dbh := newStandInDatabase(t, srv.handle)
+	_, err := dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	aborted, err := dbh.AbortStaleTransactions(context.Background(), 50*time.Millisecond)
+	assert.NoError(err, "should check the transactions")
+	assert.Empty(aborted, "should not abort recent transactions")
+	time.Sleep(60 * time.Millisecond)
+	_, err = dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	aborted, err = dbh.AbortStaleTransactions(context.Background(), 50*time.Millisecond)
+	assert.NoError(err, "should abort the stale transactions")
+	assert.ElementsMatch(
+		[]driver.TransactionID{"99", "1"},
+		aborted,
+		"should abort the transactions older than the limit",
+	)
+	assert.ElementsMatch([]string{"99", "1"}, srv.aborted, "should abort them on the server")
+}
+
+func TestStaleTransactionsSession(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{external: []string{"99"}}
+	sess := newStandInSession(t, srv.handle)
+	first, err := sess.DB(standInDB)
+	assert.NoError(err, "should get the database")
+	stale, err := first.StaleTransactions(context.Background(), 50*time.Millisecond)
+	assert.NoError(err, "should check the transactions")
+	assert.Empty(stale, "should not find transactions listed for the first time stale")
+	time.Sleep(60 * time.Millisecond)
+	second, err := sess.DB(standInDB)
+	assert.NoError(err, "should get the database again")
+	stale, err = second.StaleTransactions(context.Background(), 50*time.Millisecond)
+	assert.NoError(err, "should check the transactions")
+	assert.Equal(
+		[]*TransactionInfo{{ID: "99", State: driver.TransactionRunning}},
+		stale,
+		"should share the first seen times between the databases of the session",
+	)
+	assert.Empty(srv.aborted, "should not abort the stale transactions")
+	aborted, err := second.AbortStaleTransactions(context.Background(), 50*time.Millisecond)
+	assert.NoError(err, "should abort the stale transactions")
+	assert.Equal([]driver.TransactionID{"99"}, aborted, "should abort the listed transactions")
+}
+
+func TestSessionClose(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	sess := newStandInSession(t, srv.handle, WithTransactionTracking())
+	dbh, err := sess.DB(standInDB)
+	assert.NoError(err, "should get the database")
+	committed, err := dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	running, err := dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	assert.NoError(committed.Commit(), "should commit the transaction")
+	assert.NoError(sess.Close(), "should close the session")
+	assert.Equal(TxAborted, running.State(), "should abort the running transaction")
+	assert.Equal([]string{"1"}, srv.committed, "should keep the committed transaction")
+	assert.Equal([]string{"2"}, srv.aborted, "should abort only the running transaction")
+
+	untracked := newStandInSession(t, srv.handle)
+	dbh, err = untracked.DB(standInDB)
+	assert.NoError(err, "should get the database")
+	_, err = dbh.BeginTransaction(context.Background(), nil)
+	assert.NoError(err, "should begin the transaction")
+	assert.NoError(untracked.Close(), "should close the session")
+	assert.Equal([]string{"2"}, srv.aborted, "should not abort untracked transactions")
+}
@@ -0,0 +1,110 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+const (
+	// ErrArangoLockTimeout is the error number of a lock timeout.
+	ErrArangoLockTimeout = 18
+	maxRetryBackoff      = 5 * time.Second
+)
+
+// RunInTransaction runs fn within a stream transaction. The transaction is
+// committed if fn returns nil and aborted if fn returns an error or panics,
+// in which case the panic is propagated after the abort. The function
+// should neither commit nor abort the transaction itself.
+//
+// If the transaction fails because of a write-write conflict or a lock
+// timeout, the whole function is run again in a new transaction, up to
+// opts.MaxRetries times, waiting opts.RetryBackoff before the first retry
+// and twice as long before every further one. The function should
+// therefore not have side effects outside of the transaction. If opts is
+// nil, DefaultTransactionOptions is used.
+func (d *Database) RunInTransaction(
+	ctx context.Context,
+	opts *TransactionOptions,
+	fn func(tx *TransactionHandler) error,
+) error {
+	if opts == nil {
+		opts = DefaultTransactionOptions()
+	}
+	backoff := opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := d.runTransaction(ctx, opts, fn)
+		if err == nil || attempt >= opts.MaxRetries || !IsRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf(
+				"transaction retry canceled %w, last error %s",
+				ctx.Err(), err,
+			)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+func (d *Database) runTransaction(
+	ctx context.Context,
+	opts *TransactionOptions,
+	fn func(tx *TransactionHandler) error,
+) error {
+	trx, err := d.BeginTransaction(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			_ = abortIfRunning(trx)
+			panic(rec)
+		}
+	}()
+	if err := fn(trx); err != nil {
+		if aerr := abortIfRunning(trx); aerr != nil {
+			return fmt.Errorf("%w, %s", err, aerr)
+		}
+
+		return err
+	}
+	if trx.finished() {
+		return errors.New("transaction was finished by the function")
+	}
+
+	return trx.Commit()
+}
+
+func abortIfRunning(trx *TransactionHandler) error {
+	if trx.finished() {
+		return nil
+	}
+
+	return trx.Abort()
+}
+
+// IsRetryable checks if the error is caused by a write-write conflict or a
+// lock timeout, i.e. an error after which running the transaction again
+// could succeed.
+func IsRetryable(err error) bool {
+	var aerr driver.ArangoError
+	if errors.As(err, &aerr) {
+		return isRetryableNum(aerr.ErrorNum)
+	}
+	var paerr *driver.ArangoError
+	if errors.As(err, &paerr) {
+		return isRetryableNum(paerr.ErrorNum)
+	}
+
+	return false
+}
+
+func isRetryableNum(num int) bool {
+	return num == driver.ErrArangoConflict || num == ErrArangoLockTimeout
+}
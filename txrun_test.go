@@ -0,0 +1,279 @@
+package arangomanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// txServer is a stand-in for the stream transaction and cursor APIs, it
+// records the transactions and fails the queries with the queued errors.
+type txServer struct {
+	mu        sync.Mutex
+	begun     int
+	committed []string
+	aborted   []string
+	// error numbers returned for the next queries, one per query
+	failures []int
+	// bodies of the begin requests
+	begins []map[string]interface{}
+	// rows returned by the queries
+	rows []interface{}
+	// method, route and transaction id of the query and document requests
+	requests []string
+	// running transactions begun by other processes
+	external []string
+}
+
+var bindParamRe = regexp.MustCompile(`@@?\w+`)
+
+func (srv *txServer) handle(wrt http.ResponseWriter, req *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	prefix := "/_db/" + standInDB + "/_api/"
+	route := strings.TrimPrefix(req.URL.Path, prefix)
+	switch {
+	case req.Method == http.MethodPost && route == "transaction/begin":
+		srv.begun++
+		var body map[string]interface{}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		srv.begins = append(srv.begins, body)
+		writeJSON(wrt, http.StatusCreated, map[string]interface{}{
+			"error": false, "code": 201,
+			"result": map[string]interface{}{
+				"id": fmt.Sprintf("%d", srv.begun), "status": "running",
+			},
+		})
+	case req.Method == http.MethodGet && route == "transaction":
+		txs := make([]interface{}, 0)
+		for _, id := range srv.external {
+			txs = append(txs, map[string]interface{}{"id": id, "state": "running"})
+		}
+		for idx := 1; idx <= srv.begun; idx++ {
+			id := fmt.Sprintf("%d", idx)
+			state := "running"
+			switch {
+			case slices.Contains(srv.committed, id):
+				state = "committed"
+			case slices.Contains(srv.aborted, id):
+				state = "aborted"
+			}
+			txs = append(txs, map[string]interface{}{"id": id, "state": state})
+		}
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{"transactions": txs})
+	case strings.HasPrefix(route, "transaction/"):
+		id := strings.TrimPrefix(route, "transaction/")
+		status := "running"
+		switch req.Method {
+		case http.MethodPut:
+			srv.committed = append(srv.committed, id)
+			status = "committed"
+		case http.MethodDelete:
+			srv.aborted = append(srv.aborted, id)
+			status = "aborted"
+		}
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200,
+			"result": map[string]interface{}{"id": id, "status": status},
+		})
+	case req.Method == http.MethodPost && route == "query":
+		var body map[string]string
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		params := make([]string, 0)
+		for _, prm := range bindParamRe.FindAllString(body["query"], -1) {
+			params = append(params, strings.TrimPrefix(prm, "@"))
+		}
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200, "parsed": true,
+			"collections": []string{}, "bindVars": params, "ast": []interface{}{},
+		})
+	case strings.HasPrefix(route, "collection/"):
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200,
+			"name": strings.TrimPrefix(route, "collection/"), "type": 2,
+		})
+	case strings.HasPrefix(route, "document/"):
+		srv.requests = append(
+			srv.requests,
+			req.Method+" "+route+" "+req.Header.Get("x-arango-trx-id"),
+		)
+		key, status := path.Base(route), http.StatusCreated
+		switch req.Method {
+		case http.MethodPost:
+			key = "1"
+		case http.MethodGet, http.MethodDelete:
+			status = http.StatusOK
+		}
+		writeJSON(wrt, status, map[string]interface{}{
+			"_key": key, "_id": "stock/" + key, "_rev": "_r1", "name": "gel",
+		})
+	case req.Method == http.MethodPost && route == "cursor":
+		srv.requests = append(
+			srv.requests,
+			req.Method+" "+route+" "+req.Header.Get("x-arango-trx-id"),
+		)
+		if len(srv.failures) > 0 {
+			num := srv.failures[0]
+			srv.failures = srv.failures[1:]
+			writeJSON(wrt, http.StatusConflict, map[string]interface{}{
+				"error": true, "code": 409, "errorNum": num,
+				"errorMessage": "write-write conflict",
+			})
+
+			return
+		}
+		rows := append(make([]interface{}, 0), srv.rows...)
+		writeJSON(wrt, http.StatusCreated, map[string]interface{}{
+			"error": false, "code": 201, "result": rows,
+			"hasMore": false, "count": len(rows),
+		})
+	default:
+		writeJSON(wrt, http.StatusNotFound, map[string]interface{}{
+			"error": true, "code": 404, "errorNum": 404,
+			"errorMessage": "unexpected request " + req.URL.Path,
+		})
+	}
+}
+
+func fastRetries(retries int) *TransactionOptions {
+	opts := DefaultTransactionOptions()
+	opts.WriteCollections = []string{"stock"}
+	opts.MaxRetries = retries
+	opts.RetryBackoff = time.Millisecond
+
+	return opts
+}
+
+func TestRunInTransactionCommit(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	calls := 0
+	err := dbh.RunInTransaction(
+		context.Background(),
+		fastRetries(3),
+		func(tx *TransactionHandler) error {
+			calls++
+			return tx.Do("INSERT {} INTO stock", nil)
+		},
+	)
+	assert.NoError(err, "should run the transaction")
+	assert.Equal(1, calls, "should run the function once")
+	assert.Equal([]string{"1"}, srv.committed, "should commit the transaction")
+	assert.Empty(srv.aborted, "should not abort the transaction")
+}
+
+func TestRunInTransactionAbort(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	errFn := errors.New("invalid stock")
+	err := dbh.RunInTransaction(
+		context.Background(),
+		fastRetries(3),
+		func(tx *TransactionHandler) error {
+			if err := tx.Do("INSERT {} INTO stock", nil); err != nil {
+				return err
+			}
+			return errFn
+		},
+	)
+	assert.ErrorIs(err, errFn, "should return the error of the function")
+	assert.Equal(1, srv.begun, "should not retry on other errors")
+	assert.Equal([]string{"1"}, srv.aborted, "should abort the transaction")
+	assert.Empty(srv.committed, "should not commit the transaction")
+
+	assert.PanicsWithValue("boom", func() {
+		_ = dbh.RunInTransaction(
+			context.Background(),
+			fastRetries(3),
+			func(tx *TransactionHandler) error { panic("boom") },
+		)
+	}, "should propagate the panic")
+	assert.Equal([]string{"1", "2"}, srv.aborted, "should abort before panicking")
+}
+
+func TestRunInTransactionRetry(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{failures: []int{1200, 18}}
+	dbh := newStandInDatabase(t, srv.handle)
+	calls := 0
+	err := dbh.RunInTransaction(
+		context.Background(),
+		fastRetries(3),
+		func(tx *TransactionHandler) error {
+			calls++
+			return tx.Do("INSERT {} INTO stock", nil)
+		},
+	)
+	assert.NoError(err, "should succeed after retries")
+	assert.Equal(3, calls, "should run the function again after conflicts")
+	assert.Equal([]string{"1", "2"}, srv.aborted, "should abort the conflicting transactions")
+	assert.Equal([]string{"3"}, srv.committed, "should commit the last transaction")
+
+	srv.failures = []int{1200, 1200, 1200}
+	err = dbh.RunInTransaction(
+		context.Background(),
+		fastRetries(1),
+		func(tx *TransactionHandler) error {
+			return tx.Do("INSERT {} INTO stock", nil)
+		},
+	)
+	assert.Error(err, "should give up after the retries")
+	assert.True(IsRetryable(err), "should return the conflict")
+	assert.Equal(5, srv.begun, "should run once and retry once")
+}
+
+func TestRunInTransactionRetryQueries(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	srv := &txServer{
+		failures: []int{1200, 18},
+		rows:     []interface{}{map[string]interface{}{"name": "gel"}},
+	}
+	dbh := newStandInDatabase(t, srv.handle)
+	calls := 0
+	err := dbh.RunInTransaction(
+		context.Background(),
+		fastRetries(3),
+		func(tx *TransactionHandler) error {
+			calls++
+			rs, err := tx.SearchRows("FOR doc IN stock RETURN doc", nil)
+			if err != nil {
+				return err
+			}
+			assert.False(rs.IsEmpty(), "should return the rows")
+			return nil
+		},
+	)
+	assert.NoError(err, "should succeed after retrying the search")
+	assert.Equal(3, calls, "should run the search again after conflicts")
+
+	srv.failures = []int{1200}
+	calls = 0
+	err = dbh.RunInTransaction(
+		context.Background(),
+		fastRetries(3),
+		func(tx *TransactionHandler) error {
+			calls++
+			_, err := tx.CountWithParams("FOR doc IN stock RETURN doc", nil)
+			return err
+		},
+	)
+	assert.NoError(err, "should succeed after retrying the count")
+	assert.Equal(2, calls, "should run the count again after a conflict")
+}
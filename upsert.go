@@ -0,0 +1,168 @@
+package arangomanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+)
+
+const defaultUpsertBatch = 1000
+
+var matchFieldRegxp = regexp.MustCompile(`^[\w-]+$`)
+
+// UpsertOptions are the options of Upsert and UpsertMany, nil is the same
+// as the zero value.
+type UpsertOptions struct {
+	// Replace replaces the matching document, otherwise the attributes of
+	// the document are merged into it
+	Replace bool
+	// WaitForSync waits until the operation is synced to disk
+	WaitForSync bool
+	// BatchSize is the number of documents UpsertMany writes with a single
+	// query, 1000 if zero
+	BatchSize int `validate:"gte=0"`
+}
+
+// UpsertResult is the result of an upsert.
+type UpsertResult struct {
+	// Keys of the upserted documents, in the order of the documents
+	Keys []string
+	// Number of documents that were inserted
+	Inserted int
+	// Number of existing documents that were updated or replaced
+	Updated int
+}
+
+// upsertRow is the row returned by the upsert query for every document.
+type upsertRow struct {
+	Key      string `json:"key"`
+	Inserted bool   `json:"inserted"`
+}
+
+// Upsert inserts the document, or updates the document of the collection
+// whose matchFields attributes are equal to the ones of doc, i.e.
+//
+//	FOR doc IN @docs
+//		UPSERT { `sku`: doc.`sku` }
+//		INSERT doc
+//		UPDATE doc
+//		IN @@collection
+//		RETURN { key: NEW._key, inserted: OLD == null }
+func Upsert[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	matchFields []string,
+	doc T,
+	opts *UpsertOptions,
+) (*UpsertResult, error) {
+	return UpsertMany(ctx, dbh, collection, matchFields, []T{doc}, opts)
+}
+
+// UpsertMany upserts the documents like Upsert, with a query for every
+// opts.BatchSize documents. On error, the result covers the batches that
+// were written before the failed one.
+func UpsertMany[T any](
+	ctx context.Context,
+	dbh *Database,
+	collection string,
+	matchFields []string,
+	docs []T,
+	opts *UpsertOptions,
+) (*UpsertResult, error) {
+	if opts == nil {
+		opts = &UpsertOptions{}
+	}
+	if err := validator.New().Struct(opts); err != nil {
+		return nil, fmt.Errorf("invalid upsert options %w", err)
+	}
+	query, err := upsertQuery(matchFields, opts)
+	if err != nil {
+		return nil, err
+	}
+	size := defaultUpsertBatch
+	if opts.BatchSize > 0 {
+		size = opts.BatchSize
+	}
+	res := &UpsertResult{Keys: make([]string, 0, len(docs))}
+	for start := 0; start < len(docs); start += size {
+		batch := docs[start:min(start+size, len(docs))]
+		if err := dbh.upsertBatch(ctx, collection, query, batch, res); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// upsertQuery generates the upsert query, the match fields are part of the
+// query as they could not be bound.
+func upsertQuery(matchFields []string, opts *UpsertOptions) (string, error) {
+	if len(matchFields) == 0 {
+		return "", errors.New("no fields to match the documents")
+	}
+	matches := make([]string, 0, len(matchFields))
+	for _, field := range matchFields {
+		if !matchFieldRegxp.MatchString(field) {
+			return "", fmt.Errorf("invalid match field %q", field)
+		}
+		matches = append(matches, fmt.Sprintf("`%s`: doc.`%s`", field, field))
+	}
+	update := "UPDATE doc"
+	if opts.Replace {
+		update = "REPLACE doc"
+	}
+	clauses := []string{
+		"FOR doc IN @docs",
+		fmt.Sprintf("UPSERT { %s }", strings.Join(matches, ", ")),
+		"INSERT doc",
+		update,
+		"IN @@collection",
+	}
+	if opts.WaitForSync {
+		clauses = append(clauses, "OPTIONS { waitForSync: true }")
+	}
+	clauses = append(clauses, "RETURN { key: NEW._key, inserted: OLD == null }")
+
+	return strings.Join(clauses, "\n\t"), nil
+}
+
+// upsertBatch runs the upsert query for a batch of documents and adds its
+// rows to the result.
+func (d *Database) upsertBatch(
+	ctx context.Context,
+	collection, query string,
+	batch interface{},
+	res *UpsertResult,
+) error {
+	bindVars := map[string]interface{}{
+		"@collection": collection,
+		"docs":        batch,
+	}
+	if err := d.validateQuery(ctx, query, bindVars); err != nil {
+		return err
+	}
+	cursor, err := d.dbh.Query(ctx, query, bindVars)
+	if err != nil {
+		return &DocumentError{Op: "upsert", Collection: collection, Err: err}
+	}
+	defer func() { _ = cursor.Close() }()
+	for cursor.HasMore() {
+		row := &upsertRow{}
+		if _, err := cursor.ReadDocument(ctx, row); err != nil {
+			return fmt.Errorf("error in reading the upsert result %s", err)
+		}
+		res.Keys = append(res.Keys, row.Key)
+		if row.Inserted {
+			res.Inserted++
+		} else {
+			res.Updated++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package arangomanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var matchRe = regexp.MustCompile("`([\\w-]+)`: doc")
+
+// upsertServer is a stand-in server running the upsert queries against an
+// in-memory collection.
+type upsertServer struct {
+	mu      sync.Mutex
+	docs    []map[string]interface{}
+	queries []string
+}
+
+func (srv *upsertServer) handle(wrt http.ResponseWriter, req *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	route := strings.TrimPrefix(req.URL.Path, "/_db/"+standInDB+"/_api/")
+	switch {
+	case req.Method == http.MethodPost && route == "query":
+		var body map[string]string
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		params := make([]string, 0)
+		for _, prm := range bindParamRe.FindAllString(body["query"], -1) {
+			params = append(params, strings.TrimPrefix(prm, "@"))
+		}
+		writeJSON(wrt, http.StatusOK, map[string]interface{}{
+			"error": false, "code": 200, "parsed": true,
+			"collections": []string{}, "bindVars": params, "ast": []interface{}{},
+		})
+	case req.Method == http.MethodPost && route == "cursor":
+		var body struct {
+			Query    string `json:"query"`
+			BindVars struct {
+				Docs []map[string]interface{} `json:"docs"`
+			} `json:"bindVars"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		srv.queries = append(srv.queries, body.Query)
+		rows := make([]interface{}, 0)
+		for _, doc := range body.BindVars.Docs {
+			if doc["sku"] == "dup" {
+				writeArangoError(wrt, http.StatusConflict, 1210, "unique constraint violated")
+
+				return
+			}
+			rows = append(rows, srv.upsert(body.Query, doc))
+		}
+		writeJSON(wrt, http.StatusCreated, map[string]interface{}{
+			"error": false, "code": 201, "result": rows,
+			"hasMore": false, "count": len(rows),
+		})
+	default:
+		writeArangoError(wrt, http.StatusNotFound, 404, "unexpected request "+req.URL.Path)
+	}
+}
+
+func (srv *upsertServer) upsert(
+	query string,
+	doc map[string]interface{},
+) map[string]interface{} {
+	for _, old := range srv.docs {
+		matched := true
+		for _, match := range matchRe.FindAllStringSubmatch(query, -1) {
+			matched = matched && old[match[1]] == doc[match[1]]
+		}
+		if !matched {
+			continue
+		}
+		if strings.Contains(query, "REPLACE doc") {
+			for name := range old {
+				if name != "_key" {
+					delete(old, name)
+				}
+			}
+		}
+		for name, value := range doc {
+			old[name] = value
+		}
+
+		return map[string]interface{}{"key": old["_key"], "inserted": false}
+	}
+	doc["_key"] = fmt.Sprintf("k%d", len(srv.docs)+1)
+	srv.docs = append(srv.docs, doc)
+
+	return map[string]interface{}{"key": doc["_key"], "inserted": true}
+}
+
+type sku struct {
+	Sku   string `json:"sku"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count,omitempty"`
+}
+
+func TestUpsert(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	srv := &upsertServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	res, err := Upsert(ctx, dbh, "stock", []string{"sku"}, sku{Sku: "gel", Name: "gel"}, nil)
+	assert.NoError(err, "should upsert the document")
+	assert.Equal(&UpsertResult{Keys: []string{"k1"}, Inserted: 1}, res, "should insert the document")
+	assert.Equal(
+		"FOR doc IN @docs\n\tUPSERT { `sku`: doc.`sku` }\n\tINSERT doc\n\tUPDATE doc\n\tIN @@collection\n\tRETURN { key: NEW._key, inserted: OLD == null }",
+		srv.queries[0],
+		"should match the upsert query",
+	)
+	res, err = Upsert(ctx, dbh, "stock", []string{"sku"}, sku{Sku: "gel", Count: 4}, nil)
+	assert.NoError(err, "should upsert the existing document")
+	assert.Equal(&UpsertResult{Keys: []string{"k1"}, Updated: 1}, res, "should update the document")
+	assert.Equal("gel", srv.docs[0]["name"], "should merge into the existing document")
+
+	_, err = Upsert(ctx, dbh, "stock", []string{"sku"}, sku{Sku: "gel", Count: 5},
+		&UpsertOptions{Replace: true, WaitForSync: true})
+	assert.NoError(err, "should replace the existing document")
+	assert.Contains(srv.queries[2], "REPLACE doc", "should replace the document")
+	assert.Contains(srv.queries[2], "OPTIONS { waitForSync: true }", "should wait for sync")
+	assert.NotContains(srv.docs[0], "name", "should drop the attributes missing from the document")
+
+	_, err = Upsert(ctx, dbh, "stock", []string{"sku"}, sku{Sku: "dup"}, nil)
+	assert.ErrorIs(err, ErrConflict, "should report the conflict")
+	for _, fields := range [][]string{nil, {"sku`"}, {"sku", "props.name"}} {
+		_, err = Upsert(ctx, dbh, "stock", fields, sku{Sku: "gel"}, nil)
+		assert.Errorf(err, "should return error for match fields %v", fields)
+	}
+	_, err = Upsert(ctx, dbh, "stock", []string{"sku"}, sku{Sku: "gel"}, &UpsertOptions{BatchSize: -1})
+	assert.Error(err, "should return error for invalid options")
+}
+
+func TestUpsertMany(t *testing.T) {
+	t.Parallel()
+	assert := require.New(t)
+	ctx := context.Background()
+	srv := &upsertServer{}
+	dbh := newStandInDatabase(t, srv.handle)
+	_, err := Upsert(ctx, dbh, "stock", []string{"sku", "name"}, sku{Sku: "tip", Name: "tip"}, nil)
+	assert.NoError(err, "should upsert the document")
+	res, err := UpsertMany(ctx, dbh, "stock", []string{"sku", "name"}, []sku{
+		{Sku: "gel", Name: "gel"},
+		{Sku: "tip", Name: "tip", Count: 2},
+		{Sku: "tip", Name: "box"},
+		{Sku: "gel", Name: "gel", Count: 3},
+		{Sku: "dup"},
+	}, &UpsertOptions{BatchSize: 2})
+	assert.ErrorIs(err, ErrConflict, "should report the failed batch")
+	assert.Equal(&UpsertResult{
+		Keys:     []string{"k2", "k1", "k3", "k2"},
+		Inserted: 2,
+		Updated:  2,
+	}, res, "should report the batches written before the failure")
+	assert.Len(srv.queries, 4, "should run a query for every batch")
+	assert.Contains(srv.queries[1], "UPSERT { `sku`: doc.`sku`, `name`: doc.`name` }", "should match on every field")
+	res, err = UpsertMany(ctx, dbh, "stock", []string{"sku"}, []sku{}, nil)
+	assert.NoError(err, "should upsert no documents")
+	assert.Empty(res.Keys, "should not report any document")
+}